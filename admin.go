@@ -0,0 +1,407 @@
+package casoncelli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// adminMaxBody is the largest request body accepted by an Admin.
+const adminMaxBody = 1 << 20
+
+// AdminPeriod is a period in the responses of an Admin.
+type AdminPeriod struct {
+	// Index is the position of the period in the schedule.
+	Index  int    `json:"index"`
+	Period Period `json:"period"`
+}
+
+// AdminPeriods is the response of an Admin listing the periods.
+type AdminPeriods struct {
+	Periods []AdminPeriod `json:"periods"`
+	Version uint64        `json:"version"`
+}
+
+// AdminChange is the response of an Admin to a read or a change of a period.
+type AdminChange struct {
+	// Period is the period read, created or updated, nil when deleted.
+	Period  *AdminPeriod `json:"period,omitempty"`
+	Version uint64       `json:"version"`
+}
+
+// AdminOverride is the body of a request adding an override: an Override
+// starting now when From is missing, and lasting Duration when Until is
+// missing. An id is generated when missing.
+type AdminOverride struct {
+	Override
+	// Duration is in the format of time.ParseDuration, like "45m".
+	Duration string `json:"duration,omitempty"`
+}
+
+// adminOverrideJSON is the JSON format of an AdminOverride, which would
+// otherwise take the one of its Override.
+type adminOverrideJSON struct {
+	overrideJSON
+	Duration string `json:"duration,omitempty"`
+}
+
+func (a AdminOverride) MarshalJSON() ([]byte, error) {
+	return json.Marshal(adminOverrideJSON{a.Override.toJSON(), a.Duration})
+}
+
+func (a *AdminOverride) UnmarshalJSON(data []byte) error {
+	var aux adminOverrideJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	a.Duration = aux.Duration
+	return a.Override.fromJSON(aux.overrideJSON)
+}
+
+// AdminOverrides is the response of an Admin listing the overrides, or
+// changing them.
+type AdminOverrides struct {
+	Overrides []Override `json:"overrides"`
+	Version   uint64     `json:"version"`
+}
+
+// Admin is an http.Handler changing the periods of a Live schedule, so that
+// emergency windows can be opened without editing files by hand:
+//
+//	GET    /periods        the periods, with their index
+//	POST   /periods        append the period in the body
+//	GET    /periods/{key}  a period
+//	PUT    /periods/{key}  replace a period with the one in the body
+//	DELETE /periods/{key}  delete a period
+//	GET    /overrides      the overrides not expired yet
+//	POST   /overrides      add the override in the body, an AdminOverride
+//	DELETE /overrides/{id} end an override before its time
+//
+// The key of a period is its id or, when no period has that id, its index.
+// Adding or removing an override drops the expired ones.
+// The periods are in the JSON format of a Casoncelli, and the changes are
+// validated like a whole schedule before being published and saved to
+// Store. Changes use optimistic concurrency: they must carry the version of
+// the schedule they are based on in If-Match, which is the ETag of every
+// response, and fail with 412 Precondition Failed when the schedule has
+// changed since. The version increases with every change, including the
+// reloads of a FileLoader.
+//
+// An Admin does not authenticate its clients: wrap it in a handler that does.
+type Admin struct {
+	// Live is the schedule changed.
+	Live *Live
+	// Store saves the changed schedules, which are not persisted if nil.
+	Store Store
+	// Clock is the source of time of the overrides, SystemClock if nil.
+	Clock Clock
+
+	// mu serializes the changes, from publishing them to saving them
+	mu sync.Mutex
+}
+
+func (a *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	collection, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case collection == "periods" && key == "":
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			a.list(w)
+		case http.MethodPost:
+			a.change(w, r, "")
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			serverError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		}
+	case collection == "periods":
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			a.get(w, key)
+		case http.MethodPut, http.MethodDelete:
+			a.change(w, r, key)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT, DELETE")
+			serverError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		}
+	case collection == "overrides" && key == "":
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			a.overrides(w)
+		case http.MethodPost:
+			a.override(w, r, "")
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			serverError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		}
+	case collection == "overrides":
+		if r.Method != http.MethodDelete {
+			w.Header().Set("Allow", "DELETE")
+			serverError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+			return
+		}
+		a.override(w, r, key)
+	default:
+		serverError(w, http.StatusNotFound, "%s not found", r.URL.Path)
+	}
+}
+
+func (a *Admin) list(w http.ResponseWriter) {
+	s, version := a.Live.Load()
+	response := AdminPeriods{Periods: []AdminPeriod{}, Version: version}
+	for i, period := range s.Casoncelli().Periods {
+		response.Periods = append(response.Periods, AdminPeriod{Index: i, Period: period})
+	}
+	adminJSON(w, http.StatusOK, version, response)
+}
+
+func (a *Admin) get(w http.ResponseWriter, key string) {
+	s, version := a.Live.Load()
+	c := s.Casoncelli()
+	index, ok := adminIndex(c, key)
+	if !ok {
+		serverError(w, http.StatusNotFound, "no period %s", key)
+		return
+	}
+	adminJSON(w, http.StatusOK, version, AdminChange{Period: &AdminPeriod{Index: index, Period: c.Periods[index]}, Version: version})
+}
+
+// adminIndex returns the index of the period with the given key.
+func adminIndex(c Casoncelli, key string) (int, bool) {
+	if _, index, ok := c.PeriodByID(key); ok {
+		return index, true
+	}
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 || index >= len(c.Periods) {
+		return -1, false
+	}
+	return index, true
+}
+
+// change creates the period in the body when key is empty, replaces the one
+// with key with it on PUT, and deletes the one with key on DELETE.
+func (a *Admin) change(w http.ResponseWriter, r *http.Request, key string) {
+	expected, err := adminVersion(r)
+	if err != nil {
+		serverError(w, http.StatusPreconditionRequired, "%v", err)
+		return
+	}
+	var period Period
+	if r.Method != http.MethodDelete {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, adminMaxBody))
+		if err != nil {
+			serverError(w, http.StatusRequestEntityTooLarge, "%v", err)
+			return
+		}
+		if period, err = unmarshalAnyPeriod(body); err != nil {
+			serverError(w, http.StatusBadRequest, "invalid period: %v", err)
+			return
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.current(w, expected)
+	if !ok {
+		return
+	}
+	previous, c := s.Casoncelli(), s.Casoncelli()
+	status := http.StatusOK
+	index, ok := adminIndex(c, key)
+	switch {
+	case key == "":
+		c.Periods = append(c.Periods, period)
+		index, status = len(c.Periods)-1, http.StatusCreated
+	case !ok:
+		serverError(w, http.StatusNotFound, "no period %s", key)
+		return
+	case period == nil:
+		c.Periods = slices.Delete(c.Periods, index, index+1)
+	default:
+		c.Periods[index] = period
+	}
+
+	version, ok := a.publish(w, r, expected, previous, c)
+	if !ok {
+		return
+	}
+
+	response := AdminChange{Version: version}
+	if period != nil {
+		response.Period = &AdminPeriod{Index: index, Period: period}
+	}
+	if status == http.StatusCreated {
+		location := strconv.Itoa(index)
+		if id := labelOf(period).ID; id != "" {
+			location = url.PathEscape(id)
+		}
+		w.Header().Set("Location", "/periods/"+location)
+	}
+	adminJSON(w, status, version, response)
+}
+
+func (a *Admin) overrides(w http.ResponseWriter) {
+	s, version := a.Live.Load()
+	now := clockOrSystem(a.Clock).Now()
+	response := AdminOverrides{Overrides: []Override{}, Version: version}
+	for _, o := range s.Casoncelli().Overrides {
+		if !o.Expired(now) {
+			response.Overrides = append(response.Overrides, o)
+		}
+	}
+	adminJSON(w, http.StatusOK, version, response)
+}
+
+// override adds the override in the body when id is empty, and deletes the
+// one with id otherwise.
+func (a *Admin) override(w http.ResponseWriter, r *http.Request, id string) {
+	expected, err := adminVersion(r)
+	if err != nil {
+		serverError(w, http.StatusPreconditionRequired, "%v", err)
+		return
+	}
+	now := clockOrSystem(a.Clock).Now()
+	var added AdminOverride
+	if id == "" {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, adminMaxBody))
+		if err != nil {
+			serverError(w, http.StatusRequestEntityTooLarge, "%v", err)
+			return
+		}
+		if err := json.Unmarshal(body, &added); err != nil {
+			serverError(w, http.StatusBadRequest, "invalid override: %v", err)
+			return
+		}
+		if err := added.complete(now); err != nil {
+			serverError(w, http.StatusBadRequest, "invalid override: %v", err)
+			return
+		}
+		if added.ID == "" {
+			if added.ID, err = newOverrideID(); err != nil {
+				serverError(w, http.StatusInternalServerError, "generating the override id: %v", err)
+				return
+			}
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s, ok := a.current(w, expected)
+	if !ok {
+		return
+	}
+	previous, c := s.Casoncelli(), s.Casoncelli()
+	if id != "" && !slices.ContainsFunc(c.Overrides, func(o Override) bool { return o.ID == id && !o.Expired(now) }) {
+		serverError(w, http.StatusNotFound, "no override %s", id)
+		return
+	}
+	c.Overrides = slices.DeleteFunc(c.Overrides, func(o Override) bool {
+		return o.Expired(now) || (id != "" && o.ID == id)
+	})
+	status := http.StatusOK
+	if id == "" {
+		c.Overrides = append(c.Overrides, added.Override)
+		status = http.StatusCreated
+	}
+
+	version, ok := a.publish(w, r, expected, previous, c)
+	if !ok {
+		return
+	}
+	if status == http.StatusCreated {
+		w.Header().Set("Location", "/overrides/"+url.PathEscape(added.ID))
+	}
+	adminJSON(w, status, version, AdminOverrides{Overrides: append([]Override{}, c.Overrides...), Version: version})
+}
+
+// complete fills in the missing times of the override, starting at now.
+func (o *AdminOverride) complete(now time.Time) error {
+	if o.From.IsZero() {
+		o.From = now
+	}
+	if o.Duration != "" {
+		if !o.Until.IsZero() {
+			return errors.New("until and duration are mutually exclusive")
+		}
+		d, err := time.ParseDuration(o.Duration)
+		if err != nil {
+			return err
+		}
+		o.Until = o.From.Add(d)
+	}
+	return o.validate()
+}
+
+// newOverrideID returns a random id for an override without one.
+func newOverrideID() (string, error) {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
+
+// current returns the current schedule, writing the error response and
+// returning false if it is not at version expected.
+func (a *Admin) current(w http.ResponseWriter, expected uint64) (*Schedule, bool) {
+	s, version := a.Live.Load()
+	if version != expected {
+		serverError(w, http.StatusPreconditionFailed, "the schedule is at version %d, not %d", version, expected)
+		return nil, false
+	}
+	return s, true
+}
+
+// publish replaces the schedule with c while it is at version expected, and
+// saves it to Store, undoing the change if saving fails. It writes the error
+// response and returns false on failure.
+func (a *Admin) publish(w http.ResponseWriter, r *http.Request, expected uint64, previous, c Casoncelli) (uint64, bool) {
+	version, err := a.Live.ReplaceIf(expected, c)
+	switch {
+	case errors.Is(err, ErrVersionConflict):
+		serverError(w, http.StatusPreconditionFailed, "%v", err)
+		return version, false
+	case err != nil:
+		serverError(w, http.StatusUnprocessableEntity, "invalid schedule: %v", err)
+		return version, false
+	}
+	if a.Store != nil {
+		if err := a.Store.Save(r.Context(), c); err != nil {
+			// the change is undone, at a new version that the clients must read again
+			a.Live.ReplaceIf(version, previous)
+			serverError(w, http.StatusInternalServerError, "saving the schedule: %v", err)
+			return version, false
+		}
+	}
+	return version, true
+}
+
+// adminVersion returns the version in the If-Match header of r.
+func adminVersion(r *http.Request) (uint64, error) {
+	match := r.Header.Get("If-Match")
+	if match == "" {
+		return 0, errors.New("If-Match must carry the version of the schedule")
+	}
+	version, err := strconv.ParseUint(strings.Trim(strings.TrimPrefix(match, "W/"), `"`), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("If-Match must carry the version of the schedule, not %s", match)
+	}
+	return version, nil
+}
+
+func adminJSON(w http.ResponseWriter, status int, version uint64, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("ETag", fmt.Sprintf(`"%d"`, version))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,251 @@
+package casoncelli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryStore is a Store keeping the saved schedules in memory.
+type memoryStore struct {
+	saved []Casoncelli
+	err   error
+}
+
+func (m *memoryStore) Load(context.Context) (Casoncelli, error) {
+	if len(m.saved) == 0 {
+		return Casoncelli{}, errors.New("nothing saved")
+	}
+	return m.saved[len(m.saved)-1], nil
+}
+
+func (m *memoryStore) Save(_ context.Context, c Casoncelli) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.saved = append(m.saved, c)
+	return nil
+}
+
+func adminRequest(a *Admin, method, target, version, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if version != "" {
+		r.Header.Set("If-Match", version)
+	}
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, r)
+	return rec
+}
+
+const emergencyPeriod = `{"name": "emergency", "type": "once", "from": {"timestamp": "2025-05-06 10:00:00"}, "to": {"timestamp": "2025-05-06 12:00:00"}}`
+
+func TestAdminList(t *testing.T) {
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: &memoryStore{}}
+
+	rec := adminRequest(a, http.MethodGet, "/periods", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the periods")
+	assert.Equal(t, `"1"`, rec.Header().Get("ETag"), "Expected the version as ETag")
+	assert.JSONEq(t, `{"version": 1, "periods": [
+		{"index": 0, "period": {"type": "daily", "name": "maintenance", "description": "", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}}}
+	]}`, rec.Body.String(), "Expected the periods with their index")
+
+	rec = adminRequest(a, http.MethodGet, "/periods/0", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the period")
+	assert.Contains(t, rec.Body.String(), `"name":"maintenance"`, "Expected the period")
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodGet, "/periods/1", "", "").Code, "Expected a missing period to be not found")
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodGet, "/periods/x", "", "").Code, "Expected a malformed index to be not found")
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodGet, "/other", "", "").Code, "Expected an unknown path to be not found")
+}
+
+func TestAdminCreateUpdateDelete(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+	live := a.Live
+
+	rec := adminRequest(a, http.MethodPost, "/periods", `"1"`, emergencyPeriod)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the period to be created")
+	assert.Equal(t, "/periods/1", rec.Header().Get("Location"), "Expected the location of the new period")
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"), "Expected the new version")
+	assert.True(t, live.Contains(time.Date(2025, 5, 6, 11, 0, 0, 0, time.Local)), "Expected the emergency window to be published")
+	assert.Len(t, store.saved, 1, "Expected the schedule to be saved")
+	assert.Len(t, store.saved[0].Periods, 2, "Expected the saved schedule to hold the new period")
+
+	rec = adminRequest(a, http.MethodPut, "/periods/1", `"2"`, strings.Replace(emergencyPeriod, "12:00:00", "14:00:00", 1))
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the period to be updated")
+	var change struct {
+		Version uint64 `json:"version"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &change), "Expected a valid JSON body")
+	assert.Equal(t, uint64(3), change.Version, "Expected the new version in the body")
+	assert.True(t, live.Contains(time.Date(2025, 5, 6, 13, 0, 0, 0, time.Local)), "Expected the longer window to be published")
+
+	rec = adminRequest(a, http.MethodDelete, "/periods/0", `W/"3"`, "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the period to be deleted")
+	assert.JSONEq(t, `{"version": 4}`, rec.Body.String(), "Expected the new version")
+	assert.False(t, live.Contains(time.Date(2025, 5, 6, 2, 30, 0, 0, time.Local)), "Expected the maintenance to be deleted")
+	assert.Len(t, store.saved, 3, "Expected every change to be saved")
+	assert.Equal(t, "emergency", labelOf(store.saved[2].Periods[0]).Name, "Expected the remaining period to be saved")
+}
+
+func TestAdminVersions(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+
+	rec := adminRequest(a, http.MethodPost, "/periods", "", emergencyPeriod)
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code, "Expected changes without a version to be refused")
+
+	_, err := a.Live.Remove("maintenance")
+	assert.NoError(t, err, "Expected the schedule to change behind the admin")
+	rec = adminRequest(a, http.MethodPost, "/periods", `"1"`, emergencyPeriod)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code, "Expected a stale version to be refused")
+	assert.JSONEq(t, `{"error": "the schedule is at version 2, not 1"}`, rec.Body.String(), "Expected the current version")
+	assert.Empty(t, store.saved, "Expected nothing to be saved")
+}
+
+func TestAdminInvalid(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+
+	for body, code := range map[string]int{
+		`{"type": "hourly"}`: http.StatusBadRequest,
+		`{`:                  http.StatusBadRequest,
+		`{"type": "daily", "from": {"hour": "25:00"}, "to": {"hour": "03:00"}}`: http.StatusUnprocessableEntity,
+	} {
+		rec := adminRequest(a, http.MethodPost, "/periods", `"1"`, body)
+		assert.Equal(t, code, rec.Code, "Expected %s to be refused", body)
+	}
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodPut, "/periods/3", `"1"`, emergencyPeriod).Code, "Expected a missing period to be not found")
+	assert.Equal(t, http.StatusMethodNotAllowed, adminRequest(a, http.MethodPatch, "/periods/0", `"1"`, emergencyPeriod).Code, "Expected PATCH to be refused")
+	assert.Equal(t, uint64(1), a.Live.Version(), "Expected the schedule to not change")
+	assert.Empty(t, store.saved, "Expected nothing to be saved")
+}
+
+func TestAdminStoreFailure(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+	store.err = errors.New("disk full")
+
+	rec := adminRequest(a, http.MethodPost, "/periods", `"1"`, emergencyPeriod)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "Expected the failure to be reported")
+	assert.Contains(t, rec.Body.String(), "disk full", "Expected the error of the store")
+	assert.Len(t, a.Live.Schedule().Casoncelli().Periods, 1, "Expected the change to be undone")
+	assert.Equal(t, uint64(3), a.Live.Version(), "Expected the undo to be a new version")
+}
+
+func TestAdminFileStoreReloaded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"periods": [{"type": "daily", "name": "maintenance", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}}]}`), 0o600), "Expected the schedule file to be written")
+	live, err := NewLive(Casoncelli{})
+	assert.NoError(t, err, "Expected the schedule to compile")
+	loader := &FileLoader{Path: path, Live: live}
+	assert.NoError(t, loader.Load(), "Expected the schedule file to be loaded")
+	a := &Admin{Live: live, Store: FileStore{Path: path}}
+
+	rec := adminRequest(a, http.MethodPost, "/periods", `"2"`, emergencyPeriod)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the period to be created")
+	assert.NoError(t, loader.Load(), "Expected the saved file to be loaded")
+	rec = adminRequest(a, http.MethodPut, "/periods/1", rec.Header().Get("ETag"), strings.Replace(emergencyPeriod, "12:00:00", "14:00:00", 1))
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the version of the change to still be current after the reload")
+
+	rec = adminRequest(a, http.MethodPost, "/overrides", rec.Header().Get("ETag"), `{"duration": "1h", "reason": "incident"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the override to be added")
+	assert.NoError(t, loader.Load(), "Expected the saved file to be loaded")
+	assert.Equal(t, `"5"`, rec.Header().Get("ETag"), "Expected the version of the override")
+	assert.Equal(t, uint64(5), live.Version(), "Expected the reload to leave the version alone")
+}
+
+func TestAdminIDs(t *testing.T) {
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: &memoryStore{}}
+	period := strings.Replace(emergencyPeriod, `"name"`, `"id": "db-upgrade", "name"`, 1)
+
+	rec := adminRequest(a, http.MethodPost, "/periods", `"1"`, period)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the period to be created")
+	assert.Equal(t, "/periods/db-upgrade", rec.Header().Get("Location"), "Expected the location to use the id")
+
+	rec = adminRequest(a, http.MethodGet, "/periods/db-upgrade", "", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the period to be found by id")
+	assert.Contains(t, rec.Body.String(), `"index":1`, "Expected the index of the period")
+
+	rec = adminRequest(a, http.MethodPost, "/periods", `"2"`, period)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code, "Expected a duplicate id to be refused")
+	assert.Contains(t, rec.Body.String(), `id \"db-upgrade\" is already the one of period 1`, "Expected the duplicate to be reported")
+
+	rec = adminRequest(a, http.MethodDelete, "/periods/db-upgrade", `"2"`, "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the period to be deleted by id")
+	assert.Len(t, a.Live.Schedule().Casoncelli().Periods, 1, "Expected a single period left")
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodGet, "/periods/db-upgrade", "", "").Code, "Expected the deleted period to be not found")
+}
+
+func TestAdminOverrides(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+	clock := newFakeClock(time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC))
+	a.Clock = clock
+
+	rec := adminRequest(a, http.MethodPost, "/overrides", `"1"`, `{"id": "incident", "active": true, "duration": "45m", "reason": "database failover"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the override to be added")
+	assert.Equal(t, "/overrides/incident", rec.Header().Get("Location"), "Expected the location of the override")
+	assert.JSONEq(t, `{"version": 2, "overrides": [
+		{"id": "incident", "active": true, "from": "2025-05-06 10:00:00", "until": "2025-05-06 10:45:00", "reason": "database failover"}
+	]}`, rec.Body.String(), "Expected the override to start now")
+	assert.True(t, a.Live.Contains(time.Date(2025, 5, 6, 10, 30, 0, 0, time.UTC)), "Expected the schedule to be forced active")
+	assert.Len(t, store.saved[0].Overrides, 1, "Expected the override to be saved")
+
+	rec = adminRequest(a, http.MethodPost, "/overrides", `"2"`, `{"until": "2025-05-07 03:00:00"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code, "Expected the override to be added")
+	var overrides AdminOverrides
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides), "Expected a valid JSON body")
+	assert.Len(t, overrides.Overrides, 2, "Expected both overrides")
+	assert.NotEmpty(t, overrides.Overrides[1].ID, "Expected an id to be generated")
+	assert.False(t, a.Live.Contains(time.Date(2025, 5, 7, 2, 30, 0, 0, time.UTC)), "Expected the maintenance to be forced off")
+
+	// the incident expires
+	clock.Advance(time.Hour)
+	rec = adminRequest(a, http.MethodGet, "/overrides", "", "")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &overrides), "Expected a valid JSON body")
+	assert.Len(t, overrides.Overrides, 1, "Expected the expired override to be hidden")
+	assert.Equal(t, http.StatusNotFound, adminRequest(a, http.MethodDelete, "/overrides/incident", `"3"`, "").Code, "Expected an expired override to be not found")
+
+	rec = adminRequest(a, http.MethodDelete, "/overrides/"+overrides.Overrides[0].ID, `"3"`, "")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the override to be deleted")
+	assert.JSONEq(t, `{"version": 4, "overrides": []}`, rec.Body.String(), "Expected no overrides left")
+	assert.Empty(t, store.saved[2].Overrides, "Expected the expired override to be dropped from the saved schedule")
+}
+
+func TestAdminOverridesInvalid(t *testing.T) {
+	store := &memoryStore{}
+	a := &Admin{Live: newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Store: store}
+
+	for _, body := range []string{
+		`{`,
+		`{"duration": "soon"}`,
+		`{"duration": "-1h"}`,
+		`{"duration": "1h", "until": "2025-05-07 03:00:00"}`,
+	} {
+		rec := adminRequest(a, http.MethodPost, "/overrides", `"1"`, body)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "Expected %s to be refused", body)
+	}
+	assert.Equal(t, http.StatusPreconditionRequired, adminRequest(a, http.MethodPost, "/overrides", "", `{"duration": "1h"}`).Code, "Expected changes without a version to be refused")
+	assert.Equal(t, http.StatusMethodNotAllowed, adminRequest(a, http.MethodGet, "/overrides/x", "", "").Code, "Expected only deletes of an override")
+	assert.Empty(t, store.saved, "Expected nothing to be saved")
+}
+
+func TestAdminOverrideJSON(t *testing.T) {
+	added := AdminOverride{Override: Override{Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.Local), Reason: "database failover"}, Duration: "45m"}
+	data, err := json.Marshal(added)
+	assert.NoError(t, err, "Expected the override to be marshaled")
+	assert.JSONEq(t, `{"active": true, "from": "2025-05-06 10:00:00", "until": "", "reason": "database failover", "duration": "45m"}`, string(data), "Expected the duration next to the fields of the override")
+
+	var decoded AdminOverride
+	assert.NoError(t, json.Unmarshal(data, &decoded), "Expected the override to be unmarshaled")
+	assert.Equal(t, added, decoded, "Expected the override to survive a round trip")
+}
@@ -1,6 +1,7 @@
 package casoncelli
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -9,6 +10,13 @@ type AlwaysPeriod struct {
 	PeriodLabel
 }
 
+func (a AlwaysPeriod) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		PeriodLabel
+	}{"always", a.PeriodLabel})
+}
+
 func (a AlwaysPeriod) Contains(time.Time) bool {
 	return true
 }
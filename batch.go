@@ -0,0 +1,147 @@
+package casoncelli
+
+import (
+	"iter"
+	"math"
+	"time"
+)
+
+// Membership is the evaluation of a timestamp against a Schedule.
+type Membership struct {
+	Contained bool
+	// Index is the position in Casoncelli.Periods of the first period
+	// including the timestamp, or -1 if none does: periods excluded by
+	// others of a higher priority do not count.
+	Index int
+	// Label is the label of the period at Index.
+	Label PeriodLabel
+	// Override is the override in effect at the timestamp, if any. It
+	// decides Contained regardless of Index.
+	Override *Override
+}
+
+// Evaluate returns the membership of every timestamp of ts, in the same order.
+//
+// The timestamps should be sorted: the schedule is then walked once, reusing
+// the result of the previous timestamp until the next edge is crossed, so the
+// evaluation takes linear time. Unsorted timestamps are still evaluated
+// correctly, only slower.
+func (s *Schedule) Evaluate(ts []time.Time) []Membership {
+	result := make([]Membership, len(ts))
+	w := walker{schedule: s}
+	for i, t := range ts {
+		result[i] = w.membership(t)
+	}
+	return result
+}
+
+// EvaluateSeq is like Evaluate, but consumes a sequence of timestamps and
+// yields each of them along with its membership.
+func (s *Schedule) EvaluateSeq(ts iter.Seq[time.Time]) iter.Seq2[time.Time, Membership] {
+	return func(yield func(time.Time, Membership) bool) {
+		w := walker{schedule: s}
+		for t := range ts {
+			if !yield(t, w.membership(t)) {
+				return
+			}
+		}
+	}
+}
+
+// walker evaluates a stream of timestamps, remembering the last membership
+// and the range where it holds.
+type walker struct {
+	schedule *Schedule
+	cached   Membership
+	lo, hi   int64
+}
+
+func (w *walker) membership(t time.Time) Membership {
+	if x := t.UnixNano(); x <= w.lo || x >= w.hi {
+		w.cached, w.lo, w.hi = w.schedule.evaluate(t)
+	}
+	return w.cached
+}
+
+// evaluate returns the membership of t, along with the open range (lo, hi)
+// of unix nanoseconds around t where it does not change.
+func (s *Schedule) evaluate(t time.Time) (m Membership, lo, hi int64) {
+	x := t.UnixNano()
+	first := int32(math.MaxInt32)
+	lo, hi = math.MinInt64, math.MaxInt64
+
+	if len(s.always) > 0 {
+		first = s.always[0]
+	}
+
+	var weekly []int32
+	if s.nearTransition(t) {
+		// the periods cannot tell when they change
+		weekly, lo, hi = s.weeklyAt(t), x, x
+	} else {
+		offset := weekOffset(t)
+		var wlo, whi int64
+		weekly, wlo, whi = s.weekly.locate(offset, true)
+		lo = max(lo, shiftWall(t, offset, wlo).UnixNano())
+		hi = min(hi, shiftWall(t, offset, whi).UnixNano())
+		if len(s.recurring) > 0 {
+			// the times within an hour of t are not on the day of a change of
+			// the UTC offset either
+			lo = max(lo, x-int64(transitionMargin-25*time.Hour))
+			hi = min(hi, x+int64(transitionMargin-25*time.Hour))
+		}
+	}
+	if len(weekly) > 0 {
+		first = min(first, weekly[0])
+	}
+
+	absolute, alo, ahi := s.absolute.locate(x, false)
+	if len(absolute) > 0 {
+		first = min(first, absolute[0])
+	}
+	lo, hi = max(lo, alo), min(hi, ahi)
+
+	if s.layered {
+		first = s.including(t, weekly, absolute)
+	}
+	for _, i := range s.custom {
+		if !s.layered && i < first && s.periods[i].Contains(t) {
+			first = i
+		}
+	}
+	if len(s.custom) > 0 {
+		// custom periods cannot tell when they change
+		lo, hi = x, x
+	}
+
+	m = Membership{Index: -1}
+	if first != math.MaxInt32 {
+		m = Membership{Contained: true, Index: int(first), Label: labelOf(s.periods[first])}
+	}
+	for _, o := range s.source.Overrides {
+		from, until := o.From.UnixNano(), o.Until.UnixNano()
+		switch {
+		case x < from:
+			hi = min(hi, from)
+		case x < until:
+			lo, hi = max(lo, from-1), min(hi, until)
+		default:
+			lo = max(lo, until-1)
+		}
+	}
+	if o, ok := s.OverrideAt(t); ok {
+		m.Contained, m.Override = o.Active, &o
+	}
+	return m, lo, hi
+}
+
+// shiftWall returns the instant whose wall clock is at the given offset of
+// the same week as t, whose own offset is from.
+func shiftWall(t time.Time, from, offset int64) time.Time {
+	if offset == from {
+		return t
+	}
+	year, month, day := t.Date()
+	day += int(offset/nsPerDay - from/nsPerDay)
+	return time.Date(year, month, day, 0, 0, 0, int(offset%nsPerDay), t.Location())
+}
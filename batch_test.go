@@ -0,0 +1,150 @@
+package casoncelli
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertMemberships(t *testing.T, c Casoncelli, instants []time.Time, memberships []Membership) {
+	assert.Equal(t, len(instants), len(memberships), "Expected one membership per timestamp")
+	for i, ts := range instants {
+		expected := -1
+		for j, period := range c.Periods {
+			if c.resolve(period).Contains(ts) {
+				expected = j
+				break
+			}
+		}
+		assert.Equal(t, expected >= 0, memberships[i].Contained, "Expected containment to match at %s", ts.Format(time.RFC3339Nano))
+		assert.Equal(t, expected, memberships[i].Index, "Expected first matching period to match at %s", ts.Format(time.RFC3339Nano))
+		if expected >= 0 {
+			assert.Equal(t, labelOf(c.Periods[expected]), memberships[i].Label, "Expected label to match at %s", ts.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+func TestScheduleEvaluate(t *testing.T) {
+	from := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "business hours"}, From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}, Boundary: HalfOpen},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "weekend"}, From: DayTimeEdge{Day: time.Saturday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "00:00"}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "outage"}, From: TimestampEdge{Timestamp: from}, To: TimestampEdge{Timestamp: from.Add(6 * time.Hour)}},
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "night"}, From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: Open},
+		},
+	}
+	schedule, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	sorted := scheduleInstants()
+	slices.SortFunc(sorted, func(a, b time.Time) int { return a.Compare(b) })
+	unsorted := scheduleInstants()
+	rand.New(rand.NewSource(42)).Shuffle(len(unsorted), func(i, j int) {
+		unsorted[i], unsorted[j] = unsorted[j], unsorted[i]
+	})
+	for _, instants := range [][]time.Time{sorted, unsorted} {
+		assertMemberships(t, c, instants, schedule.Evaluate(instants))
+	}
+}
+
+func TestScheduleEvaluateAllPeriodTypes(t *testing.T) {
+	start := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		NeverPeriod{},
+		OncePeriod{From: TimestampEdge{Timestamp: start}, To: TimestampEdge{Timestamp: start.Add(2 * time.Hour)}},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(time.Hour)}, To: TimestampEdge{Timestamp: start.Add(3 * time.Hour)}, Boundary: Open},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(-time.Hour)}, To: TimestampEdge{Timestamp: start.Add(-time.Hour)}},
+	}}
+	for _, b := range []Boundary{"", Closed, HalfOpen, Open} {
+		c.Periods = append(c.Periods,
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "12:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Tuesday, Hour: "07:35"}, To: DayTimeEdge{Day: time.Thursday, Hour: "22:22"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Friday, Hour: "18:00"}, To: DayTimeEdge{Day: time.Friday, Hour: "10:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, Boundary: b},
+		)
+	}
+	c.Periods = append(c.Periods, MockPeriod{result: false})
+	schedule, _ := c.Compile()
+
+	instants := scheduleInstants()
+	slices.SortFunc(instants, func(a, b time.Time) int { return a.Compare(b) })
+	assertMemberships(t, c, instants, schedule.Evaluate(instants))
+}
+
+func TestScheduleEvaluateAcrossDST(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{From: TimeEdge{Hour: "01:00"}, To: TimeEdge{Hour: "03:30"}},
+			DailyPeriod{From: TimeEdge{Hour: "04:00"}, To: TimeEdge{Hour: "05:00"}},
+		},
+	}
+	schedule, _ := c.Compile()
+
+	var instants []time.Time
+	start := time.Date(2025, 3, 29, 20, 0, 0, 0, rome)
+	for ts := start; ts.Before(start.Add(12 * time.Hour)); ts = ts.Add(7 * time.Minute) {
+		instants = append(instants, ts)
+	}
+	start = time.Date(2025, 10, 25, 20, 0, 0, 0, rome)
+	for ts := start; ts.Before(start.Add(12 * time.Hour)); ts = ts.Add(7 * time.Minute) {
+		instants = append(instants, ts)
+	}
+	assertMemberships(t, c, instants, schedule.Evaluate(instants))
+}
+
+func TestScheduleEvaluateSeqStops(t *testing.T) {
+	c := Casoncelli{Periods: []Period{DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}}}}
+	schedule, _ := c.Compile()
+
+	count := 0
+	for range schedule.EvaluateSeq(slices.Values(scheduleInstants())) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count, "Expected the sequence to stop when requested")
+}
+
+func benchmarkInstants() []time.Time {
+	start := time.Date(2025, 4, 26, 0, 0, 0, 0, time.UTC)
+	instants := make([]time.Time, 0, 100000)
+	for i := range 100000 {
+		instants = append(instants, start.Add(time.Duration(i)*7*time.Second))
+	}
+	return instants
+}
+
+func BenchmarkCasoncelliContainsEach(b *testing.B) {
+	c := benchmarkCasoncelli()
+	instants := benchmarkInstants()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ts := range instants {
+			c.Contains(ts)
+		}
+	}
+}
+
+func BenchmarkScheduleEvaluate(b *testing.B) {
+	c := benchmarkCasoncelli()
+	schedule, _ := c.Compile()
+	instants := benchmarkInstants()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		schedule.Evaluate(instants)
+	}
+}
@@ -0,0 +1,107 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Boundary declares which edges of a period are part of the period itself.
+//
+// The zero value means that the boundary is not set: a period without a
+// boundary inherits the one of its Casoncelli, and defaults to Closed.
+type Boundary string
+
+const (
+	// Closed periods contain both edges: [from, to].
+	Closed Boundary = "closed"
+	// HalfOpen periods contain the start edge but not the end edge: [from, to).
+	HalfOpen Boundary = "half-open"
+	// Open periods contain neither edge: (from, to).
+	Open Boundary = "open"
+	// OpenClosed periods contain the end edge but not the start edge: (from, to].
+	OpenClosed Boundary = "open-closed"
+)
+
+func (b *Boundary) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	boundary := Boundary(s)
+	if err := boundary.validate(); err != nil {
+		return err
+	}
+	*b = boundary
+	return nil
+}
+
+func (b Boundary) validate() error {
+	switch b {
+	case "", Closed, HalfOpen, Open, OpenClosed:
+		return nil
+	default:
+		return fmt.Errorf("invalid boundary: %s", string(b))
+	}
+}
+
+// or returns b, or def if b is not set.
+func (b Boundary) or(def Boundary) Boundary {
+	if b == "" {
+		return def
+	}
+	return b
+}
+
+// includesStart reports whether the start edge belongs to the period.
+func (b Boundary) includesStart() bool {
+	return b != Open && b != OpenClosed
+}
+
+// includesEnd reports whether the end edge belongs to the period.
+func (b Boundary) includesEnd() bool {
+	return b == "" || b == Closed || b == OpenClosed
+}
+
+// isClosed reports whether both edges belong to the period.
+func (b Boundary) isClosed() bool {
+	return b.includesStart() && b.includesEnd()
+}
+
+// afterStart reports whether t is past the start edge e, honoring the boundary.
+func (b Boundary) afterStart(e Edge, t time.Time) bool {
+	if b.includesStart() {
+		return e.BeforeOrEqual(t)
+	}
+	return e.Before(t)
+}
+
+// beforeEnd reports whether t is not past the end edge e, honoring the boundary.
+func (b Boundary) beforeEnd(e Edge, t time.Time) bool {
+	if b.includesEnd() {
+		return e.AfterOrEqual(t)
+	}
+	return e.After(t)
+}
+
+// sameEdges reports whether t is included in a recurring period whose edges
+// coincide: a closed period lasts a single instant, while the other
+// boundaries span the whole cycle, excluding the edge itself when open.
+// Running from an edge to the same edge of the next cycle, an open-closed
+// period includes the edge as its end.
+func (b Boundary) sameEdges(e Edge, t time.Time) bool {
+	switch {
+	case b.isClosed():
+		return e.Equal(t)
+	case b == Open:
+		return !e.Equal(t)
+	default:
+		return true
+	}
+}
+
+// bounded is implemented by the periods whose edges honor a Boundary.
+type bounded interface {
+	withDefaultBoundary(Boundary) Period
+}
@@ -0,0 +1,254 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDailyPeriodBoundaryContains(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	start, _ := time.Parse(layout, "2025-08-22 09:00:00")
+	within, _ := time.Parse(layout, "2025-08-22 12:00:00")
+	end, _ := time.Parse(layout, "2025-08-22 17:00:00")
+
+	period := DailyPeriod{
+		From: TimeEdge{Hour: "09:00"},
+		To:   TimeEdge{Hour: "17:00"},
+	}
+
+	assert.True(t, period.Contains(start), "Expected default period to contain its start")
+	assert.True(t, period.Contains(end), "Expected default period to contain its end")
+
+	period.Boundary = Closed
+	assert.True(t, period.Contains(start), "Expected closed period to contain its start")
+	assert.True(t, period.Contains(end), "Expected closed period to contain its end")
+
+	period.Boundary = HalfOpen
+	assert.True(t, period.Contains(start), "Expected half-open period to contain its start")
+	assert.True(t, period.Contains(within), "Expected half-open period to contain the inner timestamp")
+	assert.False(t, period.Contains(end), "Expected half-open period to not contain its end")
+
+	period.Boundary = Open
+	assert.False(t, period.Contains(start), "Expected open period to not contain its start")
+	assert.True(t, period.Contains(within), "Expected open period to contain the inner timestamp")
+	assert.False(t, period.Contains(end), "Expected open period to not contain its end")
+
+	period.Boundary = OpenClosed
+	assert.False(t, period.Contains(start), "Expected open-closed period to not contain its start")
+	assert.True(t, period.Contains(within), "Expected open-closed period to contain the inner timestamp")
+	assert.True(t, period.Contains(end), "Expected open-closed period to contain its end")
+}
+
+func TestDailyPeriodBoundaryExternalContains(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	start, _ := time.Parse(layout, "2025-08-22 22:00:00")
+	midnight, _ := time.Parse(layout, "2025-08-23 00:00:00")
+	end, _ := time.Parse(layout, "2025-08-23 06:00:00")
+
+	period := DailyPeriod{
+		From:     TimeEdge{Hour: "22:00"},
+		To:       TimeEdge{Hour: "06:00"},
+		Boundary: HalfOpen,
+	}
+
+	assert.True(t, period.Contains(start), "Expected half-open period to contain its start")
+	assert.True(t, period.Contains(midnight), "Expected half-open period to contain midnight")
+	assert.False(t, period.Contains(end), "Expected half-open period to not contain its end")
+}
+
+func TestDailyPeriodBoundarySameHourContains(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	edge, _ := time.Parse(layout, "2025-08-22 12:00:00")
+	other, _ := time.Parse(layout, "2025-08-22 18:00:00")
+
+	period := DailyPeriod{
+		From: TimeEdge{Hour: "12:00"},
+		To:   TimeEdge{Hour: "12:00"},
+	}
+
+	period.Boundary = Closed
+	assert.True(t, period.Contains(edge), "Expected closed period to contain the single instant")
+	assert.False(t, period.Contains(other), "Expected closed period to not contain other timestamps")
+
+	period.Boundary = HalfOpen
+	assert.True(t, period.Contains(edge), "Expected half-open period to contain the edge")
+	assert.True(t, period.Contains(other), "Expected half-open period to span the whole day")
+
+	period.Boundary = Open
+	assert.False(t, period.Contains(edge), "Expected open period to not contain the edge")
+	assert.True(t, period.Contains(other), "Expected open period to span the rest of the day")
+}
+
+func TestDailyPeriodBoundarySameHourCurrentEdges(t *testing.T) {
+	now := time.Now()
+	hour := now.Add(-2 * time.Hour).Format("15:04")
+	period := DailyPeriod{
+		From:     TimeEdge{Hour: hour},
+		To:       TimeEdge{Hour: hour},
+		Boundary: HalfOpen,
+	}
+
+	cs, err := period.CurrentStart()
+	assert.NoError(t, err, "Expected no error for CurrentStart")
+	ce, err := period.CurrentEnd()
+	assert.NoError(t, err, "Expected no error for CurrentEnd")
+	assert.False(t, cs.After(now), "Expected current start to be in the past")
+	assert.True(t, ce.After(now), "Expected current end to be in the future")
+	assert.Equal(t, 24*time.Hour, ce.Sub(*cs), "Expected the occurrence to last a whole day")
+}
+
+func TestWeeklyPeriodBoundaryContains(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	// 2025-04-29 is a tuesday
+	start, _ := time.Parse(layout, "2025-04-29 07:35:00")
+	within, _ := time.Parse(layout, "2025-04-30 07:35:00")
+	end, _ := time.Parse(layout, "2025-05-01 22:22:00")
+
+	period := WeeklyPeriod{
+		From: DayTimeEdge{Day: time.Tuesday, Hour: "07:35"},
+		To:   DayTimeEdge{Day: time.Thursday, Hour: "22:22"},
+	}
+
+	period.Boundary = HalfOpen
+	assert.True(t, period.Contains(start), "Expected half-open period to contain its start")
+	assert.True(t, period.Contains(within), "Expected half-open period to contain the inner timestamp")
+	assert.False(t, period.Contains(end), "Expected half-open period to not contain its end")
+
+	period.Boundary = Open
+	assert.False(t, period.Contains(start), "Expected open period to not contain its start")
+	assert.True(t, period.Contains(within), "Expected open period to contain the inner timestamp")
+	assert.False(t, period.Contains(end), "Expected open period to not contain its end")
+}
+
+func TestWeeklyPeriodBoundarySameEdgeContains(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	edge, _ := time.Parse(layout, "2025-04-29 07:35:00")
+	other, _ := time.Parse(layout, "2025-05-02 10:00:00")
+
+	period := WeeklyPeriod{
+		From:     DayTimeEdge{Day: time.Tuesday, Hour: "07:35"},
+		To:       DayTimeEdge{Day: time.Tuesday, Hour: "07:35"},
+		Boundary: HalfOpen,
+	}
+
+	assert.True(t, period.Contains(edge), "Expected half-open period to contain the edge")
+	assert.True(t, period.Contains(other), "Expected half-open period to span the whole week")
+}
+
+func TestOncePeriodBoundaryContains(t *testing.T) {
+	layout := "2006-01-02 15:04"
+	from, _ := time.Parse(layout, "2025-04-28 12:00")
+	to, _ := time.Parse(layout, "2025-04-28 14:00")
+
+	period := OncePeriod{
+		From:     TimestampEdge{Timestamp: from},
+		To:       TimestampEdge{Timestamp: to},
+		Boundary: HalfOpen,
+	}
+
+	assert.True(t, period.Contains(from), "Expected half-open period to contain its start")
+	assert.False(t, period.Contains(to), "Expected half-open period to not contain its end")
+
+	period.Boundary = Open
+	assert.False(t, period.Contains(from), "Expected open period to not contain its start")
+	assert.True(t, period.Contains(from.Add(time.Minute)), "Expected open period to contain the inner timestamp")
+}
+
+func TestCasoncelliBoundary(t *testing.T) {
+	layout := "2006-01-02 15:04:05"
+	boundary, _ := time.Parse(layout, "2025-08-22 17:00:00")
+
+	dish := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}},
+			DailyPeriod{From: TimeEdge{Hour: "17:00"}, To: TimeEdge{Hour: "18:00"}, Boundary: Open},
+		},
+	}
+	assert.True(t, dish.Contains(boundary), "Expected closed periods to contain the shared edge")
+
+	dish.Boundary = HalfOpen
+	assert.False(t, dish.Contains(boundary), "Expected the period boundary to take precedence over the default")
+
+	dish.Periods[1] = DailyPeriod{From: TimeEdge{Hour: "17:00"}, To: TimeEdge{Hour: "18:00"}}
+	assert.True(t, dish.Contains(boundary), "Expected the shared edge to be contained once with half-open periods")
+	assert.True(t, dish.Periods[0].Contains(boundary), "Expected the default boundary to not alter the period itself")
+}
+
+func TestBoundaryUnmarshal(t *testing.T) {
+	exampleJson := `{
+   "boundary":"half-open",
+   "periods":[
+      {
+         "name":"business hours",
+         "type":"daily",
+         "from":{"hour":"09:00"},
+         "to":{"hour":"17:00"}
+      },
+      {
+         "name":"extra time",
+         "type":"daily",
+         "boundary":"closed",
+         "from":{"hour":"17:00"},
+         "to":{"hour":"18:00"}
+      }
+   ]
+}`
+
+	var dish Casoncelli
+	err := json.Unmarshal([]byte(exampleJson), &dish)
+	assert.NoError(t, err, "Expected no error during unmarshalling")
+	assert.Equal(t, HalfOpen, dish.Boundary, "Expected the default boundary to be unmarshalled")
+	assert.Equal(t, Boundary(""), dish.Periods[0].(DailyPeriod).Boundary, "Expected the first period to inherit the boundary")
+	assert.Equal(t, Closed, dish.Periods[1].(DailyPeriod).Boundary, "Expected the second period to declare its boundary")
+
+	invalidJson := `{"periods":[{"type":"daily","boundary":"ajar","from":{"hour":"09:00"},"to":{"hour":"17:00"}}]}`
+	err = json.Unmarshal([]byte(invalidJson), &dish)
+	assert.Error(t, err, "Expected an error for an invalid boundary")
+
+	invalidJson = `{"boundary":"ajar","periods":[]}`
+	err = json.Unmarshal([]byte(invalidJson), &dish)
+	assert.Error(t, err, "Expected an error for an invalid default boundary")
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	from := time.Date(2025, 2, 20, 12, 30, 0, 0, time.Local)
+	dish := Casoncelli{
+		Boundary: HalfOpen,
+		Periods: []Period{
+			WeeklyPeriod{
+				PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"},
+				From:        DayTimeEdge{Day: time.Saturday, Hour: "23:00"},
+				To:          DayTimeEdge{Day: time.Sunday, Hour: "07:00"},
+				Boundary:    Open,
+			},
+			DailyPeriod{
+				PeriodLabel: PeriodLabel{Name: "cron time"},
+				From:        TimeEdge{Hour: "02:00"},
+				To:          TimeEdge{Hour: "03:00"},
+			},
+			OncePeriod{
+				PeriodLabel: PeriodLabel{Name: "service interruption"},
+				From:        TimestampEdge{Timestamp: from},
+				To:          TimestampEdge{Timestamp: from.Add(2 * time.Hour)},
+				Boundary:    Closed,
+			},
+			NeverPeriod{PeriodLabel: PeriodLabel{Name: "never period"}},
+			AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "always period"}},
+		},
+	}
+
+	data, err := json.Marshal(&dish)
+	assert.NoError(t, err, "Expected no error during marshalling")
+
+	var result Casoncelli
+	err = json.Unmarshal(data, &result)
+	assert.NoError(t, err, "Expected no error during unmarshalling")
+	assert.Equal(t, dish.Boundary, result.Boundary, "Expected the boundary to survive the round trip")
+	assert.Equal(t, len(dish.Periods), len(result.Periods), "Expected all the periods to survive the round trip")
+	for i := range dish.Periods {
+		assert.True(t, dish.Periods[i] == result.Periods[i], "Expected period %d to survive the round trip", i)
+	}
+}
@@ -0,0 +1,166 @@
+package casoncelli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultCalendarResolution is the duration of the cells of a Calendar without a Resolution.
+const DefaultCalendarResolution = time.Hour
+
+// CalendarView is the range drawn by a Calendar.
+type CalendarView int
+
+const (
+	// WeekView draws the week, from Monday to Sunday.
+	WeekView CalendarView = iota
+	// MonthView draws the month.
+	MonthView
+)
+
+// Calendar draws a schedule as text, one row per day and one cell per
+// Resolution of the day, like:
+//
+//	Week of Monday 2025-05-05
+//	           00 03 06 09 12 15 18 21
+//	Mon 05-05 │BB██BBBB················│
+//	Tue 05-06 │··AA····················│
+//
+// Every cell is marked with the letter of the period active in it, or with
+// a block when several periods are, and a legend of the letters follows the
+// grid.
+type Calendar struct {
+	// View is the range drawn.
+	View CalendarView
+	// Resolution is the duration of a cell, which must divide a day,
+	// DefaultCalendarResolution if zero.
+	Resolution time.Duration
+	// ASCII draws the calendar with ASCII characters only.
+	ASCII bool
+}
+
+// calendarMarks are the letters of the periods, by index.
+const calendarMarks = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// Render draws the week or the month containing t, in the location of t.
+func (c Calendar) Render(w io.Writer, s *Schedule, t time.Time) error {
+	resolution := c.Resolution
+	if resolution == 0 {
+		resolution = DefaultCalendarResolution
+	}
+	if resolution < time.Minute || nsPerDay%int64(resolution) != 0 {
+		return fmt.Errorf("calendar resolution %s does not divide a day", resolution)
+	}
+	empty, several, border := "·", "█", "│"
+	if c.ASCII {
+		empty, several, border = ".", "#", "|"
+	}
+
+	year, month, day := t.Date()
+	var first time.Time
+	var days int
+	var title string
+	switch c.View {
+	case WeekView:
+		first = time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+		days = 7
+		title = "Week of " + first.Format("Monday 2006-01-02")
+	case MonthView:
+		first = time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+		days = first.AddDate(0, 1, -1).Day()
+		title = first.Format("January 2006")
+	default:
+		return fmt.Errorf("unknown calendar view %d", c.View)
+	}
+	last := first.AddDate(0, 0, days)
+	occurrences := s.Occurrences(first, last)
+	cells := int(nsPerDay / int64(resolution))
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, title)
+	fmt.Fprintf(out, "%-10s%s\n", "", calendarHeader(cells, resolution))
+	for d := range days {
+		year, month, day := first.AddDate(0, 0, d).Date()
+		start := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+		fmt.Fprintf(out, "%s %s", start.Format("Mon 01-02"), border)
+		for i := range cells {
+			end := time.Date(year, month, day, 0, 0, 0, (i+1)*int(resolution), t.Location())
+			fmt.Fprint(out, calendarCell(occurrences, start, end, empty, several))
+			start = end
+		}
+		fmt.Fprintln(out, border)
+	}
+
+	fmt.Fprintln(out)
+	for i, period := range s.periods {
+		label := labelOf(period)
+		name := label.Name
+		if name == "" {
+			name = fmt.Sprintf("period %d", i)
+		}
+		if label.Description != "" {
+			name += " (" + label.Description + ")"
+		}
+		fmt.Fprintf(out, "%c %s\n", calendarMark(i), name)
+	}
+	return out.Flush()
+}
+
+// calendarHeader returns the labels of the times of the cells, spaced
+// enough to not touch each other.
+func calendarHeader(cells int, resolution time.Duration) string {
+	every := cells
+	for k := 1; k <= cells; k++ {
+		label := 2
+		if time.Duration(k)*resolution%time.Hour != 0 {
+			label = 5
+		}
+		if k > label {
+			every = k
+			break
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < cells; i += every {
+		at := time.Duration(i) * resolution
+		label := fmt.Sprintf("%02d", int(at/time.Hour))
+		if time.Duration(every)*resolution%time.Hour != 0 {
+			label = fmt.Sprintf("%02d:%02d", int(at/time.Hour), int(at%time.Hour/time.Minute))
+		}
+		b.WriteString(label + strings.Repeat(" ", max(every-len(label), 0)))
+	}
+	// the first label is after the border
+	return " " + strings.TrimRight(b.String(), " ")
+}
+
+// calendarCell returns the mark of the cell between start and end.
+func calendarCell(occurrences []Occurrence, start, end time.Time, empty, several string) string {
+	mark := -1
+	for _, occ := range occurrences {
+		overlaps := occ.Unbounded() || (occ.Start.Before(end) && occ.End.After(start)) ||
+			(occ.Start.Equal(occ.End) && !occ.Start.Before(start) && occ.Start.Before(end))
+		switch {
+		case !overlaps || occ.Index == mark:
+		case mark >= 0:
+			return several
+		default:
+			mark = occ.Index
+		}
+	}
+	if mark < 0 {
+		return empty
+	}
+	return string(calendarMark(mark))
+}
+
+// calendarMark returns the letter of the period at index i.
+func calendarMark(i int) rune {
+	if i < len(calendarMarks) {
+		return rune(calendarMarks[i])
+	}
+	return '?'
+}
@@ -0,0 +1,72 @@
+package casoncelli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarWeek(t *testing.T) {
+	schedule := newTestLive(t,
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze", Description: "weekend"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	).Schedule()
+	var b strings.Builder
+	err := Calendar{Resolution: 3 * time.Hour, ASCII: true}.Render(&b, schedule, time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err, "Expected the calendar to be rendered")
+	assert.Equal(t, `Week of Monday 2025-05-05
+           00 09 18
+Mon 05-05 |##B.....|
+Tue 05-06 |AA......|
+Wed 05-07 |AA......|
+Thu 05-08 |AA......|
+Fri 05-09 |AA...BBB|
+Sat 05-10 |##BBBBBB|
+Sun 05-11 |##BBBBBB|
+
+A maintenance
+B freeze (weekend)
+`, b.String(), "Expected the week grid")
+}
+
+func TestCalendarMonth(t *testing.T) {
+	schedule := newTestLive(t,
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze", Description: "weekend"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	).Schedule()
+	var b strings.Builder
+	err := Calendar{View: MonthView}.Render(&b, schedule, time.Date(2025, 2, 14, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err, "Expected the calendar to be rendered")
+
+	lines := strings.Split(b.String(), "\n")
+	assert.Equal(t, "February 2025", lines[0], "Expected the month title")
+	assert.Equal(t, "           00 03 06 09 12 15 18 21", lines[1], "Expected the hours header")
+	assert.Equal(t, "Sat 02-01 │BB██BBBBBBBBBBBBBBBBBBBB│", lines[2], "Expected the first day of the month")
+	assert.Equal(t, "Fri 02-28 │··AA············BBBBBBBB│", lines[29], "Expected the last day of the month")
+	assert.Equal(t, "", lines[30], "Expected the legend after the days of the month")
+}
+
+func TestCalendarResolution(t *testing.T) {
+	schedule := newTestLive(t,
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze", Description: "weekend"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	).Schedule()
+	var b strings.Builder
+	err := Calendar{Resolution: 30 * time.Minute}.Render(&b, schedule, time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+	assert.NoError(t, err, "Expected the calendar to be rendered")
+	lines := strings.Split(b.String(), "\n")
+	assert.Equal(t, "           00  02  04  06  08  10  12  14  16  18  20  22", lines[1], "Expected a label every two hours")
+	assert.Equal(t, "Tue 05-06 │····AAA·········································│", lines[3], "Expected the cells to be half an hour")
+
+	err = Calendar{Resolution: 7 * time.Hour}.Render(&b, schedule, time.Now())
+	assert.EqualError(t, err, "calendar resolution 7h0m0s does not divide a day", "Expected an invalid resolution to be refused")
+	err = Calendar{Resolution: time.Second}.Render(&b, schedule, time.Now())
+	assert.Error(t, err, "Expected a resolution under a minute to be refused")
+}
+
+func TestCalendarHeader(t *testing.T) {
+	assert.Equal(t, " 00:00 00:30 01:00", calendarHeader(18, 5*time.Minute)[:18], "Expected the minutes when the labels are not on the hour")
+	assert.Equal(t, " 00", calendarHeader(1, 24*time.Hour), "Expected a single label for a single cell")
+}
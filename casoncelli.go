@@ -8,12 +8,19 @@ import (
 
 type Casoncelli struct {
 	Periods []Period `json:"periods"`
+	// Boundary is applied to the periods that do not declare their own.
+	Boundary Boundary `json:"boundary,omitempty"`
+	// Overrides force the schedule active or inactive for a while, taking
+	// precedence over the periods; see Override.
+	Overrides []Override `json:"overrides,omitempty"`
 	//Timezone *time.Location `json:"timezone,omitempty"`
 }
 
 func (c *Casoncelli) UnmarshalJSON(data []byte) error {
 	type rawCasoncelli struct {
-		Periods []json.RawMessage `json:"periods"`
+		Periods   []json.RawMessage `json:"periods"`
+		Boundary  Boundary          `json:"boundary"`
+		Overrides []Override        `json:"overrides"`
 	}
 
 	var rawObj rawCasoncelli
@@ -21,57 +28,124 @@ func (c *Casoncelli) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	periodTypes := map[string]func(json.RawMessage) (Period, error){
-		"weekly": unmarshalPeriod[WeeklyPeriod],
-		"daily":  unmarshalPeriod[DailyPeriod],
-		"once":   unmarshalPeriod[OncePeriod],
-		"never":  unmarshalPeriod[NeverPeriod],
-		"always": unmarshalPeriod[AlwaysPeriod],
-	}
-
 	periods := []Period{}
 	for _, raw := range rawObj.Periods {
-		var peek struct {
-			Type string `json:"type"`
-		}
-
-		if err := json.Unmarshal(raw, &peek); err != nil {
-			return err
-		}
-
-		unmarshaler, exists := periodTypes[peek.Type]
-		if !exists {
-			return fmt.Errorf("unknown period type: %s", peek.Type)
-		}
-
-		period, err := unmarshaler(raw)
+		period, err := unmarshalAnyPeriod(raw)
 		if err != nil {
 			return err
 		}
-
 		periods = append(periods, period)
 	}
 
 	c.Periods = periods
+	c.Boundary = rawObj.Boundary
+	c.Overrides = rawObj.Overrides
 	return nil
 }
 
 func (c *Casoncelli) Contains(t time.Time) bool {
+	if o, ok := c.OverrideAt(t); ok {
+		return o.Active
+	}
+	var containing []PeriodLabel
 	for _, period := range c.Periods {
-		if period.Contains(t) {
-			return true
+		if c.resolve(period).Contains(t) {
+			containing = append(containing, labelOf(period))
 		}
 	}
-	return false
+	return includes(containing)
 }
 
 func (c *Casoncelli) ContainsNow() bool {
-	for _, period := range c.Periods {
-		if period.ContainsNow() {
-			return true
+	return c.Contains(time.Now())
+}
+
+// Validate reports the first malformed period of the Casoncelli, if any.
+func (c *Casoncelli) Validate() error {
+	if err := c.Boundary.validate(); err != nil {
+		return err
+	}
+	ids := map[string]int{}
+	for i, period := range c.Periods {
+		if period == nil {
+			return fmt.Errorf("period %d: missing period", i)
+		}
+		if err := labelOf(period).Effect.validate(); err != nil {
+			return fmt.Errorf("period %d: %w", i, err)
+		}
+		if id := labelOf(period).ID; id != "" {
+			if j, ok := ids[id]; ok {
+				return fmt.Errorf("period %d: id %q is already the one of period %d", i, id, j)
+			}
+			ids[id] = i
+		}
+		v, ok := period.(validator)
+		if !ok {
+			continue
+		}
+		if err := v.validate(); err != nil {
+			return fmt.Errorf("period %d: %w", i, err)
 		}
 	}
-	return false
+	overrides := map[string]int{}
+	for i, o := range c.Overrides {
+		if o.ID != "" {
+			if j, ok := overrides[o.ID]; ok {
+				return fmt.Errorf("override %d: id %q is already the one of override %d", i, o.ID, j)
+			}
+			overrides[o.ID] = i
+		}
+		if err := o.validate(); err != nil {
+			return fmt.Errorf("override %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// PeriodByID returns the period with the given id, along with its index.
+func (c *Casoncelli) PeriodByID(id string) (Period, int, bool) {
+	if id == "" {
+		return nil, -1, false
+	}
+	for i, period := range c.Periods {
+		if labelOf(period).ID == id {
+			return period, i, true
+		}
+	}
+	return nil, -1, false
+}
+
+// resolve returns the period with the Casoncelli boundary applied, unless the
+// period declares its own.
+func (c *Casoncelli) resolve(p Period) Period {
+	if b, ok := p.(bounded); ok && c.Boundary != "" {
+		return b.withDefaultBoundary(c.Boundary)
+	}
+	return p
+}
+
+// periodTypes are the unmarshalers of the periods, by their JSON type.
+var periodTypes = map[string]func(json.RawMessage) (Period, error){
+	"weekly": unmarshalPeriod[WeeklyPeriod],
+	"daily":  unmarshalPeriod[DailyPeriod],
+	"once":   unmarshalPeriod[OncePeriod],
+	"never":  unmarshalPeriod[NeverPeriod],
+	"always": unmarshalPeriod[AlwaysPeriod],
+}
+
+// unmarshalAnyPeriod unmarshals a period of the type declared in its JSON.
+func unmarshalAnyPeriod(raw json.RawMessage) (Period, error) {
+	var peek struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &peek); err != nil {
+		return nil, err
+	}
+	unmarshaler, exists := periodTypes[peek.Type]
+	if !exists {
+		return nil, fmt.Errorf("unknown period type: %s", peek.Type)
+	}
+	return unmarshaler(raw)
 }
 
 func unmarshalPeriod[T Period](raw json.RawMessage) (Period, error) {
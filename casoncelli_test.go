@@ -82,11 +82,11 @@ func TestUnmarshal(t *testing.T) {
 				},
 				From: DayTimeEdge{
 					Day:  time.Saturday,
-					Hour: "02:00",
+					Hour: "23:00",
 				},
 				To: DayTimeEdge{
 					Day:  time.Sunday,
-					Hour: "03:00",
+					Hour: "07:00",
 				},
 			},
 			DailyPeriod{
@@ -95,10 +95,10 @@ func TestUnmarshal(t *testing.T) {
 					Description: "cleaning jobs",
 				},
 				From: TimeEdge{
-					Hour: "23:00",
+					Hour: "02:00",
 				},
 				To: TimeEdge{
-					Hour: "07:00",
+					Hour: "03:00",
 				},
 			},
 			OncePeriod{
@@ -258,3 +258,31 @@ func TestContainsNow(t *testing.T) {
 	}
 	assert.False(t, c6.ContainsNow(), "Expected ContainsNow to return false for c6")
 }
+
+func TestPeriodIDs(t *testing.T) {
+	var c Casoncelli
+	err := json.Unmarshal([]byte(`{"periods": [
+		{"id": "nightly", "name": "maintenance", "type": "daily", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}},
+		{"name": "freeze", "type": "weekly", "from": {"day": "friday", "hour": "16:00"}, "to": {"day": "monday", "hour": "08:00"}},
+		{"id": "outage", "type": "always"}
+	]}`), &c)
+	assert.NoError(t, err, "Expected the periods with ids to be unmarshalled")
+	assert.NoError(t, c.Validate(), "Expected unique ids to be valid")
+
+	period, index, ok := c.PeriodByID("outage")
+	assert.True(t, ok, "Expected the period to be found by id")
+	assert.Equal(t, 2, index, "Expected the index of the period")
+	assert.Equal(t, AlwaysPeriod{PeriodLabel: PeriodLabel{ID: "outage"}}, period, "Expected the period with the id")
+	_, _, ok = c.PeriodByID("missing")
+	assert.False(t, ok, "Expected a missing id to not be found")
+	_, _, ok = c.PeriodByID("")
+	assert.False(t, ok, "Expected the periods without id to not be found")
+
+	data, err := json.Marshal(c)
+	assert.NoError(t, err, "Expected the periods to be marshalled")
+	assert.Contains(t, string(data), `{"type":"daily","id":"nightly","name":"maintenance"`, "Expected the id to be marshalled")
+	assert.Contains(t, string(data), `{"type":"weekly","name":"freeze"`, "Expected no id for the periods without one")
+
+	c.Periods = append(c.Periods, NeverPeriod{PeriodLabel: PeriodLabel{ID: "nightly"}})
+	assert.EqualError(t, c.Validate(), `period 3: id "nightly" is already the one of period 0`, "Expected duplicate ids to be rejected")
+}
@@ -0,0 +1,228 @@
+package casoncelli
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// DefaultChartWidth is the width in pixels of a TimelineChart without a Width.
+const DefaultChartWidth = 960
+
+const (
+	chartLabelWidth = 160
+	chartMargin     = 40
+	chartLaneHeight = 28
+	chartAxisHeight = 24
+)
+
+// chartColors are the colors of the lanes of the periods, by index.
+var chartColors = []string{"#4e79a7", "#f28e2b", "#59a14f", "#e15759", "#76b7b2", "#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#bab0ac"}
+
+// TimelineChart draws the occurrences of a schedule over a range as a
+// self-contained SVG image or HTML page, one lane per period followed by a
+// lane of the merged occurrences. The name and description of the periods,
+// and the edges of every occurrence, are shown as tooltips.
+type TimelineChart struct {
+	// Title is shown above the chart.
+	Title string
+	// Width is the width of the chart in pixels, DefaultChartWidth if zero.
+	Width int
+}
+
+// chartData is the data of the chart templates.
+type chartData struct {
+	Title      string
+	From, To   string
+	Width      int
+	Height     int
+	LabelWidth int
+	LaneWidth  int
+	LaneHeight int
+	BarHeight  int
+	AxisY      int
+	Lanes      []chartLane
+	Ticks      []chartTick
+}
+
+type chartLane struct {
+	Y, BarY     int
+	Name        string
+	Description string
+	Color       string
+	Bars        []chartBar
+}
+
+type chartBar struct {
+	X, Width float64
+	Tooltip  string
+}
+
+type chartTick struct {
+	X     float64
+	Label string
+}
+
+// SVG writes the chart of the occurrences of s between from and to as an SVG image.
+func (c TimelineChart) SVG(w io.Writer, s *Schedule, from, to time.Time) error {
+	data, err := c.data(s, from, to)
+	if err != nil {
+		return err
+	}
+	return chartTemplates.ExecuteTemplate(w, "svg", data)
+}
+
+// HTML writes the chart of the occurrences of s between from and to as an HTML page.
+func (c TimelineChart) HTML(w io.Writer, s *Schedule, from, to time.Time) error {
+	data, err := c.data(s, from, to)
+	if err != nil {
+		return err
+	}
+	return chartTemplates.ExecuteTemplate(w, "html", data)
+}
+
+func (c TimelineChart) data(s *Schedule, from, to time.Time) (chartData, error) {
+	if !to.After(from) {
+		return chartData{}, errors.New("chart range ends before it starts")
+	}
+	width := c.Width
+	if width <= 0 {
+		width = DefaultChartWidth
+	}
+	if width <= chartLabelWidth+chartMargin {
+		return chartData{}, fmt.Errorf("chart width %d leaves no room for the lanes", width)
+	}
+
+	data := chartData{
+		Title:      c.Title,
+		Width:      width,
+		LabelWidth: chartLabelWidth,
+		LaneWidth:  width - chartLabelWidth - chartMargin,
+		LaneHeight: chartLaneHeight,
+		BarHeight:  chartLaneHeight - 8,
+		From:       from.Format(timestampLayout),
+		To:         to.Format(timestampLayout),
+	}
+	span := float64(to.Sub(from))
+	scale := float64(data.LaneWidth) / span
+	x := func(t time.Time) float64 {
+		return chartLabelWidth + float64(t.Sub(from))*scale
+	}
+	bar := func(occ Occurrence, name string) chartBar {
+		if occ.Unbounded() {
+			return chartBar{X: chartLabelWidth, Width: float64(data.LaneWidth), Tooltip: name + ": always"}
+		}
+		start, end := x(maxTime(occ.Start, from)), x(minTime(occ.End, to))
+		return chartBar{
+			X:       start,
+			Width:   max(end-start, 1),
+			Tooltip: fmt.Sprintf("%s: %s - %s", name, occ.Start.Format(timestampLayout), occ.End.Format(timestampLayout)),
+		}
+	}
+
+	lanes := make([]chartLane, len(s.periods))
+	for i, period := range s.periods {
+		label := labelOf(period)
+		lanes[i] = chartLane{Name: label.Name, Description: label.Description, Color: chartColors[i%len(chartColors)]}
+		if lanes[i].Name == "" {
+			lanes[i].Name = fmt.Sprintf("period %d", i)
+		}
+	}
+	for _, occ := range s.Occurrences(from, to) {
+		name := lanes[occ.Index].Name
+		if description := lanes[occ.Index].Description; description != "" {
+			name += " (" + description + ")"
+		}
+		lanes[occ.Index].Bars = append(lanes[occ.Index].Bars, bar(occ, name))
+	}
+	merged := chartLane{Name: "merged", Description: "when any period is active", Color: "#555555"}
+	for _, occ := range s.Merged(from, to) {
+		merged.Bars = append(merged.Bars, bar(occ, merged.Name))
+	}
+	data.Lanes = append(lanes, merged)
+
+	for i := range data.Lanes {
+		data.Lanes[i].Y = i * chartLaneHeight
+		data.Lanes[i].BarY = data.Lanes[i].Y + 4
+	}
+	data.AxisY = len(data.Lanes) * chartLaneHeight
+	data.Height = data.AxisY + chartAxisHeight
+	ticks, step := chartTicks(from, to)
+	layout := "Mon 01-02"
+	if step < 24*time.Hour {
+		layout = "Mon 15:04"
+	}
+	for _, t := range ticks {
+		data.Ticks = append(data.Ticks, chartTick{X: x(t), Label: t.Format(layout)})
+	}
+	return data, nil
+}
+
+// chartSteps are the candidate intervals between the ticks of the axis.
+var chartSteps = []time.Duration{time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 28 * 24 * time.Hour}
+
+// chartTicks returns the times of the ticks of the axis between from and
+// to, at most about a dozen, on round wall clock times, along with their step.
+func chartTicks(from, to time.Time) ([]time.Time, time.Duration) {
+	step := chartSteps[len(chartSteps)-1]
+	for _, candidate := range chartSteps {
+		if to.Sub(from)/candidate <= 12 {
+			step = candidate
+			break
+		}
+	}
+
+	year, month, day := from.Date()
+	var ticks []time.Time
+	for i := 0; ; i++ {
+		var t time.Time
+		if step < 24*time.Hour {
+			t = time.Date(year, month, day, i*int(step/time.Hour), 0, 0, 0, from.Location())
+		} else {
+			t = time.Date(year, month, day+i*int(step/(24*time.Hour)), 0, 0, 0, 0, from.Location())
+		}
+		if t.After(to) {
+			return ticks, step
+		}
+		if !t.Before(from) {
+			ticks = append(ticks, t)
+		}
+	}
+}
+
+var chartTemplates = template.Must(template.New("chart").Parse(`
+{{- define "svg" -}}
+<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="{{.Height}}" viewBox="0 0 {{.Width}} {{.Height}}" font-family="sans-serif" font-size="12">
+<title>{{with .Title}}{{.}}: {{end}}{{.From}} - {{.To}}</title>
+{{- range .Lanes}}
+<g class="lane">
+<text x="4" y="{{.Y}}" dy="18">{{.Name}}{{with .Description}}<title>{{.}}</title>{{end}}</text>
+<rect x="{{$.LabelWidth}}" y="{{.Y}}" width="{{$.LaneWidth}}" height="{{$.LaneHeight}}" fill="#f4f4f4"/>
+{{- $lane := .}}
+{{- range .Bars}}
+<rect x="{{printf "%.1f" .X}}" y="{{$lane.BarY}}" width="{{printf "%.1f" .Width}}" height="{{$.BarHeight}}" fill="{{$lane.Color}}"><title>{{.Tooltip}}</title></rect>
+{{- end}}
+</g>
+{{- end}}
+<g class="axis">
+{{- range .Ticks}}
+<line x1="{{printf "%.1f" .X}}" y1="0" x2="{{printf "%.1f" .X}}" y2="{{$.AxisY}}" stroke="#bbbbbb" stroke-dasharray="2 2"/>
+<text x="{{printf "%.1f" .X}}" y="{{$.AxisY}}" dy="16" text-anchor="middle">{{.Label}}</text>
+{{- end}}
+</g>
+</svg>
+{{- end}}
+{{- define "html" -}}
+<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{with .Title}}{{.}}{{else}}Schedule{{end}}</title></head>
+<body style="font-family: sans-serif">
+<h1>{{with .Title}}{{.}}{{else}}Schedule{{end}}</h1>
+<p>From {{.From}} to {{.To}}</p>
+{{template "svg" .}}
+</body>
+</html>
+{{end}}
+`))
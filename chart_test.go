@@ -0,0 +1,73 @@
+package casoncelli
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimelineChartSVG(t *testing.T) {
+	schedule := newTestLive(t,
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze", Description: "weekend"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	).Schedule()
+	from := time.Date(2025, 5, 8, 0, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	err := TimelineChart{Title: "Maintenance"}.SVG(&b, schedule, from, from.AddDate(0, 0, 4))
+	assert.NoError(t, err, "Expected the chart to be rendered")
+
+	var svg struct {
+		XMLName xml.Name `xml:"svg"`
+		Lanes   []struct {
+			Text  string `xml:"text"`
+			Rects []struct {
+				Fill  string `xml:"fill,attr"`
+				Title string `xml:"title"`
+			} `xml:"rect"`
+		} `xml:"g"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(b.String()), &svg), "Expected a well-formed SVG image")
+	assert.Len(t, svg.Lanes, 4, "Expected a lane per period, the merged lane and the axis")
+	assert.Equal(t, "maintenance", svg.Lanes[0].Text, "Expected the period name as the lane label")
+	assert.Len(t, svg.Lanes[0].Rects, 5, "Expected the background and the four maintenance occurrences")
+	assert.Equal(t, "maintenance: 2025-05-08 02:00:00 - 2025-05-08 03:30:00", svg.Lanes[0].Rects[1].Title, "Expected the edges in the tooltip")
+	assert.Equal(t, "freeze (weekend): 2025-05-09 16:00:00 - 2025-05-12 08:00:00", svg.Lanes[1].Rects[1].Title, "Expected the description in the tooltip")
+	assert.Equal(t, "merged", svg.Lanes[2].Text, "Expected the merged lane last")
+	assert.Len(t, svg.Lanes[2].Rects, 4, "Expected the background and the three merged occurrences")
+	assert.Contains(t, b.String(), ">Fri 12:00</text>", "Expected a tick every twelve hours")
+
+	b.Reset()
+	assert.NoError(t, TimelineChart{}.SVG(&b, schedule, from, from.AddDate(0, 1, 0)), "Expected the chart to be rendered")
+	assert.Contains(t, b.String(), ">Thu 05-15</text>", "Expected a tick every week")
+}
+
+func TestTimelineChartHTML(t *testing.T) {
+	schedule := newTestLive(t,
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze", Description: "weekend"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	).Schedule()
+	from := time.Date(2025, 5, 8, 0, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	err := TimelineChart{Title: "<script>"}.HTML(&b, schedule, from, from.Add(12*time.Hour))
+	assert.NoError(t, err, "Expected the chart to be rendered")
+	assert.True(t, strings.HasPrefix(b.String(), "<!DOCTYPE html>"), "Expected an HTML page")
+	assert.Contains(t, b.String(), "<h1>&lt;script&gt;</h1>", "Expected the title to be escaped")
+	assert.Contains(t, b.String(), "<svg", "Expected the chart inline")
+	assert.Contains(t, b.String(), "Thu 03:00", "Expected the hours on the axis of a short range")
+
+	err = TimelineChart{}.HTML(&b, schedule, from, from)
+	assert.Error(t, err, "Expected an empty range to be refused")
+	err = TimelineChart{Width: 100}.HTML(&b, schedule, from, from.Add(time.Hour))
+	assert.Error(t, err, "Expected a narrow chart to be refused")
+}
+
+func TestTimelineChartAlways(t *testing.T) {
+	s, _ := (&Casoncelli{Periods: []Period{AlwaysPeriod{}}}).Compile()
+	from := time.Date(2025, 5, 8, 0, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	assert.NoError(t, TimelineChart{}.SVG(&b, s, from, from.Add(time.Hour)), "Expected the chart to be rendered")
+	assert.Contains(t, b.String(), "<title>period 0: always</title>", "Expected an unbounded occurrence to fill the lane")
+}
@@ -0,0 +1,113 @@
+package casoncelli
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a Clock whose wall clock and elapsed time are driven by the test.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	elapsed time.Duration
+	waiters []fakeWaiter
+	calls   int
+}
+
+type fakeWaiter struct {
+	deadline time.Duration
+	ch       chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls++
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeWaiter{deadline: c.elapsed + d, ch: ch})
+	return ch
+}
+
+// Advance lets d pass, moving the wall clock along.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.elapsed += d
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline <= c.elapsed {
+			w.ch <- c.now
+		} else {
+			pending = append(pending, w)
+		}
+	}
+	c.waiters = pending
+}
+
+// Jump moves the wall clock by d without letting any time pass.
+func (c *fakeClock) Jump(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Calls returns how many times After was called.
+func (c *fakeClock) Calls() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.calls
+}
+
+// waitCalls waits until After has been called at least n times.
+func (c *fakeClock) waitCalls(t *testing.T, n int) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return c.Calls() >= n }, time.Second, time.Millisecond, "Expected the clock to be waited on %d times", n)
+}
+
+func TestSystemClock(t *testing.T) {
+	before := time.Now()
+	now := SystemClock.Now()
+	assert.False(t, now.Before(before), "Expected the system clock to tell the current time")
+
+	select {
+	case <-SystemClock.After(time.Millisecond):
+	case <-time.After(time.Second):
+		assert.Fail(t, "Expected the system clock to wait")
+	}
+
+	assert.Equal(t, SystemClock, clockOrSystem(nil), "Expected a nil clock to default to the system clock")
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	ch := clock.After(time.Hour)
+
+	clock.Jump(2 * time.Hour)
+	select {
+	case <-ch:
+		assert.Fail(t, "Expected a wall clock jump to not fire the timer")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, start.Add(3*time.Hour), <-ch, "Expected the timer to fire after the elapsed time")
+}
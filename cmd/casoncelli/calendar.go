@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+func (c *cli) calendar(args []string) int {
+	var opts options
+	fs := c.flags("calendar", "", &opts)
+	at := fs.String("at", "", "draw the week or the month of `time` instead of now")
+	month := fs.Bool("month", false, "draw the month instead of the week")
+	resolution := fs.Duration("resolution", casoncelli.DefaultCalendarResolution, "`duration` of a cell")
+	ascii := fs.Bool("ascii", false, "draw with ASCII characters only")
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+	if opts.json {
+		return c.fail(errors.New("calendar has no JSON output"))
+	}
+	s, err := load(opts.schedule)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	t := c.clock.Now()
+	if *at != "" {
+		if t, err = parseTime(*at); err != nil {
+			return c.fail(err)
+		}
+	}
+	calendar := casoncelli.Calendar{Resolution: *resolution, ASCII: *ascii}
+	if *month {
+		calendar.View = casoncelli.MonthView
+	}
+	if err := calendar.Render(c.stdout, s, t.In(time.Local)); err != nil {
+		return c.fail(err)
+	}
+	return exitTrue
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+// exitNotRun is the exit code of exec when the command cannot be started,
+// like in a shell.
+const exitNotRun = 127
+
+func (c *cli) exec(args []string) int {
+	var opts options
+	fs := c.flags("exec", "-- <command> [arguments]", &opts)
+	inside := fs.Bool("inside", false, "run the command only while the schedule is active")
+	stop := fs.Bool("stop", false, "terminate the command when its allowed time ends")
+	grace := fs.Duration("grace", 10*time.Second, "time between SIGTERM and SIGKILL with -stop")
+	skipCode := fs.Int("skip-exit-code", 0, "exit code when the command is not allowed to run")
+	if err := fs.Parse(args); err != nil {
+		return c.fail(err)
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return c.fail(errors.New("missing command"))
+	}
+	s, err := load(opts.schedule)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	now := c.clock.Now()
+	if s.Contains(now) != *inside {
+		fmt.Fprintf(c.stderr, "casoncelli: not running %s: %s\n", fs.Arg(0), c.skipReason(s, now, *inside))
+		return *skipCode
+	}
+
+	ctx := context.Background()
+	if *stop {
+		live, err := casoncelli.NewLive(s.Casoncelli())
+		if err != nil {
+			return c.fail(err)
+		}
+		var cancel context.CancelFunc
+		if *inside {
+			ctx, cancel = casoncelli.ContextUntilCurrentEnd(ctx, live)
+		} else {
+			ctx, cancel = casoncelli.ContextUntilNextStart(ctx, live)
+		}
+		defer cancel()
+	}
+
+	// the command writes its errors along with this process
+	stderr := &syncWriter{w: c.stderr}
+	cmd := exec.CommandContext(ctx, fs.Arg(0), fs.Args()[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, c.stdout, stderr
+	cmd.Cancel = func() error {
+		fmt.Fprintf(stderr, "casoncelli: terminating %s: %v\n", fs.Arg(0), context.Cause(ctx))
+		return cmd.Process.Signal(syscall.SIGTERM)
+	}
+	cmd.WaitDelay = *grace
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(c.stderr, "casoncelli: %v\n", err)
+		return exitNotRun
+	}
+
+	// the signals meant to stop this process are meant for the command too
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+	go func() {
+		for sig := range signals {
+			cmd.Process.Signal(sig)
+		}
+	}()
+
+	// the exit code of the command is propagated, even after it was terminated
+	if err := cmd.Wait(); cmd.ProcessState == nil {
+		fmt.Fprintf(c.stderr, "casoncelli: %v\n", err)
+		return exitNotRun
+	}
+	if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return cmd.ProcessState.ExitCode()
+}
+
+// skipReason tells why a command is not allowed to run at t.
+func (c *cli) skipReason(s *casoncelli.Schedule, t time.Time, inside bool) string {
+	if inside {
+		if next := s.NextMerged(t, 1); len(next) > 0 {
+			return "the schedule is not active until " + next[0].Start.Format(displayLayout)
+		}
+		return "the schedule is not active"
+	}
+	g, _ := gateAt(s, t, 0)
+	reason := "the schedule is active"
+	if g.Reason != nil {
+		reason = g.Reason.label() + " is active"
+	}
+	if g.End != nil {
+		reason += " until " + g.End.Format(displayLayout)
+	}
+	return reason
+}
+
+// syncWriter serializes the writes to w.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valmoz/casoncelli"
+)
+
+// execCLI returns a cli on the system clock, with a schedule made of a once
+// period between from and to.
+func execCLI(t *testing.T, from, to time.Time) (*cli, *bytes.Buffer, *bytes.Buffer) {
+	data, err := json.Marshal(casoncelli.Casoncelli{Periods: []casoncelli.Period{casoncelli.OncePeriod{
+		PeriodLabel: casoncelli.PeriodLabel{Name: "maintenance"},
+		From:        casoncelli.TimestampEdge{Timestamp: from},
+		To:          casoncelli.TimestampEdge{Timestamp: to},
+	}}})
+	assert.NoError(t, err, "Expected the schedule to be marshalled")
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o644), "Expected the schedule file to be written")
+
+	var stdout, stderr bytes.Buffer
+	return &cli{
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string {
+			if key == "CASONCELLI_SCHEDULE" {
+				return path
+			}
+			return ""
+		},
+		clock: casoncelli.SystemClock,
+	}, &stdout, &stderr
+}
+
+func TestExec(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	c, stdout, stderr := execCLI(t, now.Add(time.Hour), now.Add(2*time.Hour))
+
+	assert.Equal(t, 3, c.run([]string{"exec", "--", "sh", "-c", "echo running; exit 3"}), "Expected the exit code of the command")
+	assert.Equal(t, "running\n", stdout.String(), "Expected the command to run outside the maintenance")
+
+	assert.Equal(t, 0, c.run([]string{"exec", "-inside", "--", "sh", "-c", "echo running"}), "Expected a skipped command to succeed")
+	assert.Equal(t, 4, c.run([]string{"exec", "-inside", "-skip-exit-code", "4", "--", "true"}), "Expected the exit code of a skipped command")
+	assert.Contains(t, stderr.String(), "not running sh: the schedule is not active until", "Expected the skip to be reported")
+	assert.Equal(t, "running\n", stdout.String(), "Expected the command to not run")
+
+	assert.Equal(t, exitNotRun, c.run([]string{"exec", "--", "casoncelli-missing-command"}), "Expected a missing command to not run")
+	assert.Equal(t, exitError, c.run([]string{"exec"}), "Expected the command to be required")
+}
+
+func TestExecActive(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	c, stdout, stderr := execCLI(t, now.Add(-time.Hour), now.Add(time.Hour))
+
+	assert.Equal(t, 0, c.run([]string{"exec", "--", "echo", "running"}), "Expected a skipped command to succeed")
+	assert.Empty(t, stdout.String(), "Expected the command to not run during the maintenance")
+	assert.Contains(t, stderr.String(), "not running echo: maintenance is active until", "Expected the skip to be reported")
+
+	assert.Equal(t, 0, c.run([]string{"exec", "-inside", "--", "echo", "running"}), "Expected the command to succeed")
+	assert.Equal(t, "running\n", stdout.String(), "Expected the command to run inside the maintenance")
+}
+
+func TestExecStop(t *testing.T) {
+	// the maintenance starts while the command runs
+	start := time.Now().Truncate(time.Second).Add(2 * time.Second)
+	c, _, stderr := execCLI(t, start, start.Add(time.Hour))
+
+	began := time.Now()
+	assert.Equal(t, 128+15, c.run([]string{"exec", "-stop", "--", "sleep", "10"}), "Expected the command to be terminated by SIGTERM")
+	assert.Less(t, time.Since(began), 5*time.Second, "Expected the command to be terminated when the maintenance starts")
+	assert.Contains(t, stderr.String(), "terminating sleep: casoncelli: schedule became active", "Expected the termination to be reported")
+
+	start = time.Now().Truncate(time.Second).Add(2 * time.Second)
+	c, _, _ = execCLI(t, start, start.Add(time.Hour))
+	script := `trap "" TERM; exec sleep 10`
+	assert.Equal(t, 128+9, c.run([]string{"exec", "-stop", "-grace", "100ms", "--", "sh", "-c", script}), "Expected the command ignoring SIGTERM to be killed")
+}
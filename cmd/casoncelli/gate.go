@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+// gate is the output of the gate command.
+type gate struct {
+	Time  time.Time `json:"time"`
+	Clear bool      `json:"clear"`
+	// Reason is the period blocking the gate.
+	Reason *occurrence `json:"reason,omitempty"`
+	// Start and End are the edges of the merged occurrence blocking the gate.
+	Start *time.Time `json:"start,omitempty"`
+	End   *time.Time `json:"end,omitempty"`
+	// TimedOut reports whether the gate is still blocked after the timeout of -wait.
+	TimedOut bool `json:"timed_out,omitempty"`
+}
+
+// gateAt returns the state of the gate at t, which is clear if the schedule
+// is not active from t for at least d.
+func gateAt(s *casoncelli.Schedule, t time.Time, d time.Duration) (gate, casoncelli.Occurrence) {
+	g := gate{Time: t, Clear: true}
+	next := s.NextMerged(t, 1)
+	if len(next) == 0 {
+		return g, casoncelli.Occurrence{}
+	}
+	blocking := next[0]
+	if !blocking.Contains(t) && !blocking.Start.Before(t.Add(d)) {
+		return g, casoncelli.Occurrence{}
+	}
+
+	g.Clear = false
+	reasons := s.Active(t)
+	if len(reasons) == 0 {
+		reasons = s.Next(t, 1)
+	}
+	if len(reasons) > 0 {
+		reason := outputOf(reasons[0])
+		g.Reason = &reason
+	}
+	if !blocking.Unbounded() {
+		g.Start, g.End = &blocking.Start, &blocking.End
+	}
+	return g, blocking
+}
+
+func (c *cli) gate(args []string) int {
+	var opts options
+	fs := c.flags("gate", "", &opts)
+	at := fs.String("at", "", "check the gate at `time` instead of now")
+	wait := fs.Bool("wait", false, "wait until the gate is clear")
+	timeout := fs.Duration("timeout", 0, "give up waiting after `duration`, no limit if zero")
+	clearFor := fs.Duration("until-clear-for", 0, "require the schedule to stay inactive for `duration`")
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+	if *at != "" && *wait {
+		return c.fail(errors.New("-at and -wait are mutually exclusive"))
+	}
+	s, err := load(opts.schedule)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	now := c.clock.Now()
+	if *at != "" {
+		if now, err = parseTime(*at); err != nil {
+			return c.fail(err)
+		}
+	}
+	deadline := now.Add(*timeout)
+
+	for {
+		g, blocking := gateAt(s, now, *clearFor)
+		if !g.Clear && *wait && !blocking.Unbounded() {
+			if *timeout > 0 && blocking.End.After(deadline) {
+				g.TimedOut = true
+			} else {
+				fmt.Fprintf(c.stderr, "casoncelli: waiting until %s\n", blocking.End.Format(displayLayout))
+				// a closed occurrence still includes its end
+				<-c.clock.After(max(blocking.End.Sub(now), time.Nanosecond))
+				now = c.clock.Now()
+				continue
+			}
+		}
+
+		if opts.json {
+			c.printJSON(g)
+		} else {
+			c.printGate(g, *clearFor)
+		}
+		if !g.Clear {
+			return exitFalse
+		}
+		return exitTrue
+	}
+}
+
+func (c *cli) printGate(g gate, clearFor time.Duration) {
+	if g.Clear {
+		fmt.Fprintf(c.stdout, "clear at %s\n", g.Time.Format(displayLayout))
+		return
+	}
+
+	reason := "the schedule"
+	if g.Reason != nil {
+		reason = g.Reason.label()
+	}
+	switch {
+	case g.End == nil:
+		fmt.Fprintf(c.stdout, "blocked by %s, with no end in sight\n", reason)
+	case g.Start.After(g.Time):
+		fmt.Fprintf(c.stdout, "blocked by %s within %s, from %s until %s\n", reason, clearFor, g.Start.Format(displayLayout), g.End.Format(displayLayout))
+	default:
+		fmt.Fprintf(c.stdout, "blocked by %s until %s\n", reason, g.End.Format(displayLayout))
+	}
+	if g.TimedOut {
+		fmt.Fprintln(c.stdout, "the wait would exceed the timeout")
+	}
+}
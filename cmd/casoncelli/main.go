@@ -0,0 +1,242 @@
+// Command casoncelli evaluates the schedules of the casoncelli library from
+// the command line.
+//
+// Usage:
+//
+//	casoncelli <command> [flags] [arguments]
+//
+// The schedule is read from the JSON file given by the -schedule flag, or by
+// the CASONCELLI_SCHEDULE environment variable. Every command accepts the
+// -json flag to print its result as JSON.
+//
+// The commands reporting whether the schedule is active exit with 0 when it
+// is, and with 1 when it is not; gate exits with 1 while the schedule is
+// active, and validate with 1 for an invalid schedule. The exit code is 2
+// for every other error. exec exits with the exit code of the command it
+// runs.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+const (
+	exitTrue  = 0
+	exitFalse = 1
+	exitError = 2
+)
+
+// cli is the environment the commands run in.
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	clock  casoncelli.Clock
+}
+
+// command is a subcommand of the tool.
+type command struct {
+	name    string
+	summary string
+	run     func(c *cli, args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"status", "tell whether the schedule is active now", (*cli).status},
+		{"at", "tell whether the schedule is active at a time", (*cli).at},
+		{"next", "list the next occurrences", (*cli).next},
+		{"validate", "check a schedule file", (*cli).validate},
+		{"gate", "fail while the schedule is active, like a deploy freeze", (*cli).gate},
+		{"exec", "run a command only while the schedule is not active", (*cli).exec},
+		{"calendar", "draw the week or the month of the schedule", (*cli).calendar},
+		{"serve", "serve the schedule over HTTP, reloading the file when it changes", (*cli).serve},
+	}
+}
+
+func main() {
+	c := &cli{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv, clock: casoncelli.SystemClock}
+	os.Exit(c.run(os.Args[1:]))
+}
+
+func (c *cli) run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		c.usage()
+		if len(args) == 0 {
+			return exitError
+		}
+		return exitTrue
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+	fmt.Fprintf(c.stderr, "casoncelli: unknown command %q\n", args[0])
+	c.usage()
+	return exitError
+}
+
+func (c *cli) usage() {
+	fmt.Fprintln(c.stderr, "Usage: casoncelli <command> [flags] [arguments]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// options are the flags shared by the commands.
+type options struct {
+	schedule string
+	json     bool
+}
+
+// flags returns the flag set of a command, with the shared flags.
+func (c *cli) flags(name, arguments string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.StringVar(&opts.schedule, "schedule", c.getenv("CASONCELLI_SCHEDULE"), "schedule JSON `file`")
+	fs.BoolVar(&opts.json, "json", false, "print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: casoncelli %s [flags] %s\n\nFlags:\n", name, arguments)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags of a command, checking the number of its arguments.
+func parse(fs *flag.FlagSet, args []string, arguments int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != arguments {
+		fs.Usage()
+		return errors.New("wrong number of arguments")
+	}
+	return nil
+}
+
+// errInvalid marks the errors of a schedule file that is not valid.
+var errInvalid = errors.New("invalid schedule")
+
+// load reads the schedule file and compiles it.
+func load(path string) (*casoncelli.Schedule, error) {
+	if path == "" {
+		return nil, errors.New("no schedule file, use -schedule or CASONCELLI_SCHEDULE")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c casoncelli.Casoncelli
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalid, path, err)
+	}
+	s, err := c.Compile()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", errInvalid, path, err)
+	}
+	return s, nil
+}
+
+// fail prints err and returns the exit code for it.
+func (c *cli) fail(err error) int {
+	if !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(c.stderr, "casoncelli: %v\n", err)
+	}
+	return exitError
+}
+
+func (c *cli) printJSON(v any) {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// timeLayouts are the layouts accepted for the times on the command line,
+// other than RFC 3339, in the local time zone.
+var timeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"}
+
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or \"2006-01-02 15:04:05\"", s)
+}
+
+const displayLayout = "2006-01-02 15:04:05 MST"
+
+// occurrence is the output of an Occurrence.
+type occurrence struct {
+	ID          string              `json:"id,omitempty"`
+	Name        string              `json:"name,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Period      *int                `json:"period,omitempty"`
+	Start       *time.Time          `json:"start,omitempty"`
+	End         *time.Time          `json:"end,omitempty"`
+	Boundary    casoncelli.Boundary `json:"boundary,omitempty"`
+}
+
+func outputOf(occ casoncelli.Occurrence) occurrence {
+	o := occurrence{ID: occ.Label.ID, Name: occ.Label.Name, Description: occ.Label.Description, Boundary: occ.Boundary}
+	if occ.Index >= 0 {
+		o.Period = &occ.Index
+	}
+	if !occ.Unbounded() {
+		o.Start, o.End = &occ.Start, &occ.End
+	}
+	return o
+}
+
+func outputsOf(occurrences []casoncelli.Occurrence) []occurrence {
+	result := []occurrence{}
+	for _, occ := range occurrences {
+		result = append(result, outputOf(occ))
+	}
+	return result
+}
+
+func (o occurrence) String() string {
+	var b strings.Builder
+	if o.Start == nil {
+		b.WriteString("always")
+	} else {
+		fmt.Fprintf(&b, "%s - %s", o.Start.Format(displayLayout), o.End.Format(displayLayout))
+	}
+	if label := o.label(); label != "" {
+		fmt.Fprintf(&b, "  %s", label)
+	}
+	return b.String()
+}
+
+// label describes the period of the occurrence.
+func (o occurrence) label() string {
+	var label string
+	switch {
+	case o.Name != "":
+		label = o.Name
+	case o.Period != nil:
+		label = fmt.Sprintf("period %d", *o.Period)
+	}
+	if o.Description != "" {
+		label += fmt.Sprintf(" (%s)", o.Description)
+	}
+	return label
+}
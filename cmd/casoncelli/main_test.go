@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchedule = `{"periods": [
+	{"name": "maintenance", "description": "update indexes", "type": "daily", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}},
+	{"name": "freeze", "type": "weekly", "from": {"day": "friday", "hour": "16:00"}, "to": {"day": "monday", "hour": "08:00"}}
+]}`
+
+// testCLI returns a cli at now, with the schedule file in CASONCELLI_SCHEDULE.
+func testCLI(t *testing.T, now time.Time, schedule string) (*cli, *bytes.Buffer, *bytes.Buffer) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	assert.NoError(t, os.WriteFile(path, []byte(schedule), 0o644), "Expected the schedule file to be written")
+	var stdout, stderr bytes.Buffer
+	return &cli{
+		stdout: &stdout,
+		stderr: &stderr,
+		getenv: func(key string) string {
+			if key == "CASONCELLI_SCHEDULE" {
+				return path
+			}
+			return ""
+		},
+		clock: &testClock{now: now},
+	}, &stdout, &stderr
+}
+
+// testClock is a Clock whose waits pass instantly.
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestStatus(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC), testSchedule)
+
+	assert.Equal(t, exitTrue, c.run([]string{"status"}), "Expected the exit code of an active schedule")
+	assert.Equal(t, `active at 2025-05-05 02:30:00 UTC
+  2025-05-02 16:00:00 UTC - 2025-05-05 08:00:00 UTC  freeze
+  2025-05-05 02:00:00 UTC - 2025-05-05 03:00:00 UTC  maintenance (update indexes)
+ends at 2025-05-05 08:00:00 UTC
+`, stdout.String(), "Expected the active periods and the end")
+
+	stdout.Reset()
+	assert.Equal(t, exitTrue, c.run([]string{"status", "-json"}), "Expected the exit code of an active schedule")
+	var st status
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &st), "Expected a valid JSON output")
+	assert.True(t, st.Active, "Expected the schedule to be active")
+	assert.Len(t, st.Periods, 2, "Expected the active periods")
+	assert.Equal(t, time.Date(2025, 5, 5, 8, 0, 0, 0, time.UTC), st.End.UTC(), "Expected the end")
+}
+
+func TestStatusOverride(t *testing.T) {
+	schedule := strings.Replace(testSchedule, "]}", `], "overrides": [
+	{"active": false, "from": "2025-05-05 02:00:00", "until": "2025-05-05 02:45:00", "reason": "release"}
+]}`, 1)
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), schedule)
+
+	assert.Equal(t, exitFalse, c.run([]string{"at", "2025-05-05T02:15:00Z"}), "Expected the schedule to be forced inactive")
+	assert.Equal(t, `inactive at 2025-05-05 02:15:00 UTC
+forced inactive until 2025-05-05 02:45:00 UTC (release)
+next start at 2025-05-05 02:45:00 UTC
+`, stdout.String(), "Expected the override and the end of the override")
+}
+
+func TestAt(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC), testSchedule)
+
+	assert.Equal(t, exitFalse, c.run([]string{"at", "2025-05-06T12:00:00Z"}), "Expected the exit code of an inactive schedule")
+	assert.Equal(t, `inactive at 2025-05-06 12:00:00 UTC
+next start at 2025-05-07 02:00:00 UTC
+`, stdout.String(), "Expected the next start")
+
+	assert.Equal(t, exitError, c.run([]string{"at", "tomorrow"}), "Expected an invalid time to be an error")
+	assert.Equal(t, exitError, c.run([]string{"at"}), "Expected the time to be required")
+}
+
+func TestNext(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC), testSchedule)
+
+	assert.Equal(t, exitTrue, c.run([]string{"next", "-n", "2"}), "Expected the next occurrences")
+	assert.Equal(t, `2025-05-06 02:00:00 UTC - 2025-05-06 03:00:00 UTC  maintenance (update indexes)
+2025-05-07 02:00:00 UTC - 2025-05-07 03:00:00 UTC  maintenance (update indexes)
+`, stdout.String(), "Expected the next occurrences of every period")
+
+	stdout.Reset()
+	assert.Equal(t, exitTrue, c.run([]string{"next", "-n", "1", "-per-period"}), "Expected the next occurrences")
+	assert.Equal(t, `maintenance:
+  2025-05-06 02:00:00 UTC - 2025-05-06 03:00:00 UTC
+freeze:
+  2025-05-09 16:00:00 UTC - 2025-05-12 08:00:00 UTC
+`, stdout.String(), "Expected the next occurrence of each period")
+
+	stdout.Reset()
+	assert.Equal(t, exitTrue, c.run([]string{"next", "-n", "5", "-merged", "-json"}), "Expected the next occurrences")
+	var merged []occurrence
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &merged), "Expected a valid JSON output")
+	assert.Len(t, merged, 5, "Expected the requested number of occurrences")
+	assert.Nil(t, merged[0].Period, "Expected merged occurrences to have no period")
+	assert.Equal(t, time.Date(2025, 5, 9, 16, 0, 0, 0, time.UTC), merged[4].Start.UTC(), "Expected the freeze to absorb the maintenance")
+	assert.Equal(t, time.Date(2025, 5, 12, 8, 0, 0, 0, time.UTC), merged[4].End.UTC(), "Expected the end of the freeze")
+
+	assert.Equal(t, exitError, c.run([]string{"next", "-merged", "-per-period"}), "Expected the modes to be exclusive")
+}
+
+func TestValidate(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Now(), testSchedule)
+	assert.Equal(t, exitTrue, c.run([]string{"validate"}), "Expected a valid schedule")
+
+	c, stdout, _ = testCLI(t, time.Now(), `{"periods": [{"type": "daily", "from": {"hour": "25:00"}, "to": {"hour": "03:00"}}]}`)
+	assert.Equal(t, exitFalse, c.run([]string{"validate", "-json"}), "Expected an invalid schedule")
+	var v validation
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &v), "Expected a valid JSON output")
+	assert.False(t, v.Valid, "Expected the schedule to be invalid")
+	assert.Contains(t, v.Error, "period 0: invalid hour value: 25", "Expected the validation error")
+
+	c, _, _ = testCLI(t, time.Now(), `{"periods": [{"type": "yearly"}]}`)
+	assert.Equal(t, exitFalse, c.run([]string{"validate"}), "Expected an unknown period type to be invalid")
+	assert.Equal(t, exitError, c.run([]string{"validate", "-schedule", "missing.json"}), "Expected a missing file to be an error")
+}
+
+func TestUsage(t *testing.T) {
+	c, _, stderr := testCLI(t, time.Now(), testSchedule)
+	assert.Equal(t, exitError, c.run(nil), "Expected a command to be required")
+	assert.Equal(t, exitError, c.run([]string{"unknown"}), "Expected an unknown command to be an error")
+	assert.Contains(t, stderr.String(), `unknown command "unknown"`, "Expected the unknown command to be reported")
+	assert.Equal(t, exitTrue, c.run([]string{"help"}), "Expected the help to succeed")
+}
+
+func TestGate(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 9, 17, 0, 0, 0, time.UTC), testSchedule)
+
+	assert.Equal(t, exitFalse, c.run([]string{"gate"}), "Expected the gate to be blocked during the freeze")
+	assert.Equal(t, "blocked by freeze until 2025-05-12 08:00:00 UTC\n", stdout.String(), "Expected the reason and the end of the freeze")
+
+	stdout.Reset()
+	assert.Equal(t, exitTrue, c.run([]string{"gate", "-at", "2025-05-12T12:00:00Z"}), "Expected the gate to be clear after the freeze")
+	assert.Equal(t, "clear at 2025-05-12 12:00:00 UTC\n", stdout.String(), "Expected the gate to be clear")
+
+	stdout.Reset()
+	assert.Equal(t, exitFalse, c.run([]string{"gate", "-at", "2025-05-13T01:00:00Z", "-until-clear-for", "2h"}), "Expected the upcoming maintenance to block the gate")
+	assert.Equal(t, "blocked by maintenance (update indexes) within 2h0m0s, from 2025-05-13 02:00:00 UTC until 2025-05-13 03:00:00 UTC\n", stdout.String(), "Expected the upcoming maintenance")
+
+	stdout.Reset()
+	assert.Equal(t, exitFalse, c.run([]string{"gate", "-wait", "-timeout", "1h", "-json"}), "Expected the wait to time out")
+	var g gate
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &g), "Expected a valid JSON output")
+	assert.True(t, g.TimedOut, "Expected the timeout to be reported")
+	assert.Equal(t, "freeze", g.Reason.Name, "Expected the reason")
+
+	assert.Equal(t, exitError, c.run([]string{"gate", "-wait", "-at", "2025-05-12T12:00:00Z"}), "Expected -wait to wait from now")
+}
+
+func TestGateWait(t *testing.T) {
+	c, stdout, stderr := testCLI(t, time.Date(2025, 5, 12, 1, 0, 0, 0, time.UTC), testSchedule)
+
+	// the freeze ends at 08:00, after the maintenance between 02:00 and 03:00
+	assert.Equal(t, exitTrue, c.run([]string{"gate", "-wait", "-until-clear-for", "6h"}), "Expected the gate to clear")
+	assert.Equal(t, "clear at 2025-05-12 08:00:00 UTC\n", stdout.String(), "Expected the gate to clear when the freeze ends")
+	assert.Contains(t, stderr.String(), "waiting until 2025-05-12 08:00:00 UTC", "Expected the wait to be reported")
+}
+
+func TestCalendar(t *testing.T) {
+	c, stdout, _ := testCLI(t, time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC), testSchedule)
+
+	assert.Equal(t, exitTrue, c.run([]string{"calendar", "-ascii", "-resolution", "3h", "-at", "2025-05-07 12:00"}), "Expected the calendar to be drawn")
+	assert.Contains(t, stdout.String(), "Week of Monday 2025-05-05\n", "Expected the week of the time")
+	assert.Contains(t, stdout.String(), "Tue 05-06 |A.......|\n", "Expected the maintenance to be marked")
+	assert.Contains(t, stdout.String(), "A maintenance (update indexes)\nB freeze\n", "Expected the legend")
+
+	stdout.Reset()
+	assert.Equal(t, exitTrue, c.run([]string{"calendar", "-month", "-at", "2025-02-10"}), "Expected the calendar to be drawn")
+	assert.Contains(t, stdout.String(), "February 2025\n", "Expected the month of the time")
+
+	assert.Equal(t, exitError, c.run([]string{"calendar", "-resolution", "7h"}), "Expected an invalid resolution to be an error")
+}
+
+func TestServeInvalid(t *testing.T) {
+	c, _, stderr := testCLI(t, time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC), `{"periods": [{"type": "daily", "from": {"hour": "25:00"}, "to": {"hour": "03:00"}}]}`)
+
+	assert.Equal(t, exitError, c.run([]string{"serve", "-addr", "127.0.0.1:0"}), "Expected an invalid schedule to not be served")
+	assert.Contains(t, stderr.String(), "schedule.json", "Expected the error to name the file")
+	assert.Equal(t, exitError, c.run([]string{"serve", "-json"}), "Expected -json to be rejected")
+}
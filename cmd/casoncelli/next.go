@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+// periodOccurrences is the output of the next command for a single period.
+type periodOccurrences struct {
+	Period      int          `json:"period"`
+	Name        string       `json:"name,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Occurrences []occurrence `json:"occurrences"`
+}
+
+func (c *cli) next(args []string) int {
+	var opts options
+	fs := c.flags("next", "", &opts)
+	n := fs.Int("n", 5, "number of occurrences")
+	merged := fs.Bool("merged", false, "merge the overlapping occurrences")
+	perPeriod := fs.Bool("per-period", false, "list the occurrences of every period")
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+	if *merged && *perPeriod {
+		return c.fail(errors.New("-merged and -per-period are mutually exclusive"))
+	}
+	if *n < 1 {
+		return c.fail(errors.New("-n must be positive"))
+	}
+	s, err := load(opts.schedule)
+	if err != nil {
+		return c.fail(err)
+	}
+
+	now := c.clock.Now()
+	if !*perPeriod {
+		next := s.Next(now, *n)
+		if *merged {
+			next = s.NextMerged(now, *n)
+		}
+		outputs := outputsOf(next)
+		if opts.json {
+			c.printJSON(outputs)
+			return exitTrue
+		}
+		for _, o := range outputs {
+			fmt.Fprintln(c.stdout, o)
+		}
+		return exitTrue
+	}
+
+	periods, err := nextPerPeriod(s, now, *n)
+	if err != nil {
+		return c.fail(err)
+	}
+	if opts.json {
+		c.printJSON(periods)
+		return exitTrue
+	}
+	for _, p := range periods {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("period %d", p.Period)
+		}
+		fmt.Fprintf(c.stdout, "%s:\n", name)
+		for _, o := range p.Occurrences {
+			o.Name, o.Description, o.Period = "", "", nil
+			fmt.Fprintf(c.stdout, "  %s\n", o)
+		}
+	}
+	return exitTrue
+}
+
+// nextPerPeriod returns the next n occurrences of every period of s.
+func nextPerPeriod(s *casoncelli.Schedule, now time.Time, n int) ([]periodOccurrences, error) {
+	source := s.Casoncelli()
+	result := []periodOccurrences{}
+	for i, period := range source.Periods {
+		single := casoncelli.Casoncelli{Boundary: source.Boundary, Periods: []casoncelli.Period{period}}
+		schedule, err := single.Compile()
+		if err != nil {
+			return nil, err
+		}
+		next := schedule.Next(now, n)
+		for j := range next {
+			next[j].Index = i
+		}
+		p := periodOccurrences{Period: i, Occurrences: outputsOf(next)}
+		if l, ok := period.(interface{ Label() casoncelli.PeriodLabel }); ok {
+			p.Name, p.Description = l.Label().Name, l.Label().Description
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
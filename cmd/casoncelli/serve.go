@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+// shutdownTimeout is how long serve waits for the requests in flight when stopped.
+const shutdownTimeout = 5 * time.Second
+
+func (c *cli) serve(args []string) int {
+	var opts options
+	fs := c.flags("serve", "", &opts)
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	reload := fs.Duration("reload", casoncelli.DefaultReloadInterval, "check the schedule file for changes every `duration`")
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+	if opts.json {
+		return c.fail(errors.New("serve always answers with JSON"))
+	}
+	if opts.schedule == "" {
+		return c.fail(errors.New("no schedule file, use -schedule or CASONCELLI_SCHEDULE"))
+	}
+
+	// the server does not start without a valid schedule, and keeps the last one afterwards
+	live := &casoncelli.Live{}
+	stderr := &syncWriter{w: c.stderr}
+	loader := &casoncelli.FileLoader{
+		Path:           opts.schedule,
+		Live:           live,
+		Interval:       *reload,
+		ReloadOnSIGHUP: true,
+		OnError: func(err error) {
+			fmt.Fprintf(stderr, "casoncelli: keeping the previous schedule: %v\n", err)
+		},
+		OnReload: func(version uint64) {
+			if version > 1 {
+				fmt.Fprintf(stderr, "casoncelli: reloaded %s, version %d\n", opts.schedule, version)
+			}
+		},
+	}
+	if err := loader.Load(); err != nil {
+		return c.fail(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return c.fail(err)
+	}
+	server := &http.Server{
+		Handler: &casoncelli.Server{
+			Live:      live,
+			Clock:     c.clock,
+			ICalendar: casoncelli.ICalendar{Location: time.Local},
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go loader.Run(ctx)
+
+	fmt.Fprintf(stderr, "casoncelli: serving %s on http://%s\n", opts.schedule, listener.Addr())
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(listener)
+	}()
+	select {
+	case err := <-errs:
+		return c.fail(err)
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return c.fail(err)
+	}
+	return exitTrue
+}
@@ -0,0 +1,68 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valmoz/casoncelli"
+)
+
+func TestServe(t *testing.T) {
+	c, _, _ := testCLI(t, time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC), testSchedule)
+	reader, writer := io.Pipe()
+	c.stderr = writer
+	exit := make(chan int, 1)
+	go func() {
+		exit <- c.run([]string{"serve", "-addr", "127.0.0.1:0", "-reload", "10ms"})
+		writer.Close()
+	}()
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(reader)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	serving := <-lines
+	assert.Contains(t, serving, "serving", "Expected the address to be announced")
+	url := serving[strings.Index(serving, "http://"):]
+	status := func() casoncelli.ServerStatus {
+		resp, err := http.Get(url + "/status")
+		assert.NoError(t, err, "Expected the status to be served")
+		defer resp.Body.Close()
+		var st casoncelli.ServerStatus
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&st), "Expected a valid JSON body")
+		return st
+	}
+	st := status()
+	assert.True(t, st.Active, "Expected the schedule to be active")
+	assert.Equal(t, uint64(1), st.Version, "Expected the first version")
+
+	// the size changes too, in case the modification time does not
+	schedule := strings.Replace(testSchedule, `"update indexes", "type": "daily", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}`, `"vacuum", "type": "daily", "from": {"hour": "02:00"}, "to": {"hour": "02:15"}`, 1)
+	assert.NoError(t, os.WriteFile(c.getenv("CASONCELLI_SCHEDULE"), []byte(schedule), 0o644), "Expected the schedule file to be rewritten")
+	assert.Contains(t, <-lines, "reloaded", "Expected the reload to be logged")
+	st = status()
+	assert.Equal(t, uint64(2), st.Version, "Expected the schedule to be reloaded")
+	assert.Len(t, st.Periods, 1, "Expected only the freeze to be active")
+
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGINT), "Expected the interrupt to be sent")
+	select {
+	case code := <-exit:
+		assert.Equal(t, exitTrue, code, "Expected the server to stop cleanly")
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the server to stop")
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valmoz/casoncelli"
+)
+
+// status is the output of the status and at commands.
+type status struct {
+	Time      time.Time    `json:"time"`
+	Active    bool         `json:"active"`
+	Periods   []occurrence `json:"periods"`
+	End       *time.Time   `json:"end,omitempty"`
+	NextStart *time.Time   `json:"next_start,omitempty"`
+	// Override is the override deciding Active, if any.
+	Override *casoncelli.Override `json:"override,omitempty"`
+}
+
+func statusAt(s *casoncelli.Schedule, t time.Time) status {
+	e := s.Explain(t)
+	st := status{Time: t, Active: e.Contained, Periods: outputsOf(e.Occurrences), Override: e.Override}
+	if end, ok := s.CurrentEnd(t); ok {
+		st.End = &end
+	}
+	if !st.Active {
+		if next := s.NextMerged(t, 1); len(next) > 0 && !next[0].Unbounded() {
+			st.NextStart = &next[0].Start
+		}
+	}
+	return st
+}
+
+func (c *cli) status(args []string) int {
+	var opts options
+	fs := c.flags("status", "", &opts)
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+	return c.report(opts, c.clock.Now())
+}
+
+func (c *cli) at(args []string) int {
+	var opts options
+	fs := c.flags("at", "<time>", &opts)
+	if err := parse(fs, args, 1); err != nil {
+		return c.fail(err)
+	}
+	t, err := parseTime(fs.Arg(0))
+	if err != nil {
+		return c.fail(err)
+	}
+	return c.report(opts, t)
+}
+
+// report prints the status of the schedule at t.
+func (c *cli) report(opts options, t time.Time) int {
+	s, err := load(opts.schedule)
+	if err != nil {
+		return c.fail(err)
+	}
+	st := statusAt(s, t)
+	if opts.json {
+		c.printJSON(st)
+	} else {
+		c.printStatus(st)
+	}
+	if !st.Active {
+		return exitFalse
+	}
+	return exitTrue
+}
+
+func (c *cli) printStatus(st status) {
+	if !st.Active {
+		fmt.Fprintf(c.stdout, "inactive at %s\n", st.Time.Format(displayLayout))
+		c.printOverride(st.Override)
+		if st.NextStart != nil {
+			fmt.Fprintf(c.stdout, "next start at %s\n", st.NextStart.Format(displayLayout))
+		}
+		return
+	}
+	fmt.Fprintf(c.stdout, "active at %s\n", st.Time.Format(displayLayout))
+	c.printOverride(st.Override)
+	for _, o := range st.Periods {
+		fmt.Fprintf(c.stdout, "  %s\n", o)
+	}
+	if st.End != nil {
+		fmt.Fprintf(c.stdout, "ends at %s\n", st.End.Format(displayLayout))
+	} else {
+		fmt.Fprintln(c.stdout, "no end in sight")
+	}
+}
+
+func (c *cli) printOverride(o *casoncelli.Override) {
+	if o == nil {
+		return
+	}
+	state := "inactive"
+	if o.Active {
+		state = "active"
+	}
+	fmt.Fprintf(c.stdout, "forced %s until %s", state, o.Until.Format(displayLayout))
+	if o.Reason != "" {
+		fmt.Fprintf(c.stdout, " (%s)", o.Reason)
+	}
+	fmt.Fprintln(c.stdout)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// validation is the output of the validate command.
+type validation struct {
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+func (c *cli) validate(args []string) int {
+	var opts options
+	fs := c.flags("validate", "", &opts)
+	if err := parse(fs, args, 0); err != nil {
+		return c.fail(err)
+	}
+
+	_, err := load(opts.schedule)
+	if err != nil && !errors.Is(err, errInvalid) {
+		return c.fail(err)
+	}
+	v := validation{Valid: err == nil}
+	if err != nil {
+		v.Error = err.Error()
+	}
+
+	switch {
+	case opts.json:
+		c.printJSON(v)
+	case v.Valid:
+		fmt.Fprintf(c.stdout, "%s: valid\n", opts.schedule)
+	default:
+		fmt.Fprintf(c.stdout, "%s\n", v.Error)
+	}
+	if !v.Valid {
+		return exitFalse
+	}
+	return exitTrue
+}
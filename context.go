@@ -0,0 +1,74 @@
+package casoncelli
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrScheduleActive is the cause of the contexts canceled because the
+	// schedule became active.
+	ErrScheduleActive = errors.New("casoncelli: schedule became active")
+	// ErrScheduleInactive is the cause of the contexts canceled because the
+	// schedule stopped being active.
+	ErrScheduleInactive = errors.New("casoncelli: schedule became inactive")
+)
+
+// ContextUntilNextStart returns a copy of ctx which is canceled when the
+// schedule of l becomes active, with ErrScheduleActive as its cause. If the
+// schedule is already active, the context is canceled right away.
+func ContextUntilNextStart(ctx context.Context, l *Live) (context.Context, context.CancelFunc) {
+	return contextUntil(ctx, l, SystemClock, true, ErrScheduleActive)
+}
+
+// ContextUntilCurrentEnd returns a copy of ctx which is canceled when the
+// schedule of l stops being active, with ErrScheduleInactive as its cause.
+// If the schedule is not active, the context is canceled right away.
+func ContextUntilCurrentEnd(ctx context.Context, l *Live) (context.Context, context.CancelFunc) {
+	return contextUntil(ctx, l, SystemClock, false, ErrScheduleInactive)
+}
+
+// WaitUntilInactive blocks until the schedule of l is not active, returning
+// nil, or until ctx is done, returning its cause.
+func WaitUntilInactive(ctx context.Context, l *Live) error {
+	return waitUntil(ctx, l, SystemClock, false)
+}
+
+func contextUntil(parent context.Context, l *Live, clock Clock, active bool, cause error) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	go func() {
+		if waitUntil(ctx, l, clock, active) == nil {
+			cancel(cause)
+		}
+	}()
+	return ctx, func() { cancel(context.Canceled) }
+}
+
+// waitUntil blocks until the schedule of l is active, or not active if
+// active is false, or until ctx is done. The schedule is checked again at
+// every transition, every time it is replaced, and at least every
+// DefaultWatchInterval.
+func waitUntil(ctx context.Context, l *Live, clock Clock, active bool) error {
+	for {
+		changed := l.Changed()
+		schedule := l.Schedule()
+		now := clock.Now()
+		if schedule.Contains(now) == active {
+			return nil
+		}
+
+		wait := DefaultWatchInterval
+		// a transition at now, like the end of a closed period, is only
+		// visible an instant later
+		if edge, ok := schedule.nextEdge(now.Add(-time.Nanosecond)); ok && edge.Sub(now) < wait {
+			wait = max(edge.Sub(now), time.Nanosecond)
+		}
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-changed:
+		case <-clock.After(wait):
+		}
+	}
+}
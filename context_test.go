@@ -0,0 +1,99 @@
+package casoncelli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func assertNotDone(t *testing.T, ctx context.Context, msg string) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		assert.Fail(t, msg)
+	default:
+	}
+}
+
+func assertDone(t *testing.T, ctx context.Context, cause error, msg string) {
+	t.Helper()
+	select {
+	case <-ctx.Done():
+		assert.ErrorIs(t, context.Cause(ctx), cause, msg)
+		assert.ErrorIs(t, ctx.Err(), context.Canceled, "Expected a canceled context")
+	case <-time.After(time.Second):
+		assert.Fail(t, msg)
+	}
+}
+
+func TestContextUntilNextStart(t *testing.T) {
+	live := newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})
+	clock := newFakeClock(time.Date(2025, 5, 5, 1, 30, 0, 0, time.UTC))
+	ctx, cancel := contextUntil(context.Background(), live, clock, true, ErrScheduleActive)
+	defer cancel()
+
+	clock.waitCalls(t, 1)
+	assertNotDone(t, ctx, "Expected the context to live until the start")
+	clock.Advance(30 * time.Minute)
+	assertDone(t, ctx, ErrScheduleActive, "Expected the context to be canceled at the start")
+
+	ctx, cancel = contextUntil(context.Background(), live, clock, true, ErrScheduleActive)
+	defer cancel()
+	assertDone(t, ctx, ErrScheduleActive, "Expected the context to be canceled while the schedule is active")
+}
+
+func TestContextUntilCurrentEnd(t *testing.T) {
+	live := newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	ctx, cancel := contextUntil(context.Background(), live, clock, false, ErrScheduleInactive)
+	defer cancel()
+
+	clock.waitCalls(t, 1)
+	clock.Advance(30 * time.Minute)
+	clock.waitCalls(t, 2)
+	assertNotDone(t, ctx, "Expected the context to live through the closed end")
+	clock.Advance(time.Nanosecond)
+	assertDone(t, ctx, ErrScheduleInactive, "Expected the context to be canceled after the end")
+}
+
+func TestContextUntilReplaced(t *testing.T) {
+	live := newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	ctx, cancel := contextUntil(context.Background(), live, clock, false, ErrScheduleInactive)
+	defer cancel()
+
+	clock.waitCalls(t, 1)
+	_, err := live.Replace(Casoncelli{})
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	assertDone(t, ctx, ErrScheduleInactive, "Expected the context to be canceled when the period is removed")
+}
+
+func TestContextUntilCancel(t *testing.T) {
+	live := newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})
+	clock := newFakeClock(time.Date(2025, 5, 5, 1, 30, 0, 0, time.UTC))
+	ctx, cancel := contextUntil(context.Background(), live, clock, true, ErrScheduleActive)
+	cancel()
+	assertDone(t, ctx, context.Canceled, "Expected the context to be canceled by its cancel function")
+}
+
+func TestWaitUntilInactive(t *testing.T) {
+	live := newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+
+	done := make(chan error)
+	go func() {
+		done <- waitUntil(context.Background(), live, clock, false)
+	}()
+	clock.waitCalls(t, 1)
+	clock.Advance(31 * time.Minute)
+	assert.NoError(t, <-done, "Expected the wait to return once the schedule is inactive")
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrScheduleActive)
+	assert.NoError(t, WaitUntilInactive(ctx, &Live{}), "Expected an empty schedule to be inactive")
+	_, err := live.Replace(Casoncelli{Periods: []Period{AlwaysPeriod{}}})
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	assert.ErrorIs(t, WaitUntilInactive(ctx, live), ErrScheduleActive, "Expected the cause of the context")
+}
@@ -1,6 +1,7 @@
 package casoncelli
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
@@ -9,19 +10,32 @@ import (
 
 type DailyPeriod struct {
 	PeriodLabel
-	From TimeEdge `json:"from"`
-	To   TimeEdge `json:"to"`
+	Validity
+	From     TimeEdge `json:"from"`
+	To       TimeEdge `json:"to"`
+	Boundary Boundary `json:"boundary,omitempty"`
+}
+
+func (p DailyPeriod) MarshalJSON() ([]byte, error) {
+	type daily DailyPeriod
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		daily
+	}{"daily", daily(p)})
 }
 
 // Contains reports whether the time instant t is included in the period.
 func (p DailyPeriod) Contains(t time.Time) bool {
+	if !p.covers(t) {
+		return false
+	}
 	switch {
 	case p.From.Hour < p.To.Hour:
-		return p.From.BeforeOrEqual(t) && p.To.AfterOrEqual(t)
+		return p.Boundary.afterStart(p.From, t) && p.Boundary.beforeEnd(p.To, t)
 	case p.From.Hour > p.To.Hour:
-		return p.To.AfterOrEqual(t) || p.From.BeforeOrEqual(t)
+		return p.Boundary.beforeEnd(p.To, t) || p.Boundary.afterStart(p.From, t)
 	default:
-		return p.From.Equal(t)
+		return p.Boundary.sameEdges(p.From, t)
 	}
 }
 
@@ -33,9 +47,12 @@ func (p DailyPeriod) ContainsNow() bool {
 // CurrentStart returns the start time of the current occurrence of the period, if active.
 func (p DailyPeriod) CurrentStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(activeOccurrence(p, now))
+	}
 	if p.Contains(now) {
 		startTime, err := p.From.GetEdgeTimestamp(now)
-		if p.From.Hour > p.To.Hour && now.Format("15:04") < p.From.Hour {
+		if p.wraps() && now.Format("15:04") < p.From.Hour {
 			startTime = startTime.AddDate(0, 0, -1)
 		}
 		return &startTime, err
@@ -46,9 +63,12 @@ func (p DailyPeriod) CurrentStart() (*time.Time, error) {
 // CurrentEnd returns the end time of the current occurrence of the period, if active.
 func (p DailyPeriod) CurrentEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(activeOccurrence(p, now))
+	}
 	if p.Contains(now) {
 		endTime, err := p.To.GetEdgeTimestamp(now)
-		if p.From.Hour > p.To.Hour && now.Format("15:04") >= p.From.Hour {
+		if p.wraps() && now.Format("15:04") >= p.From.Hour {
 			endTime = endTime.AddDate(0, 0, 1)
 		}
 		return &endTime, err
@@ -59,6 +79,9 @@ func (p DailyPeriod) CurrentEnd() (*time.Time, error) {
 // NextStart returns the start time of the next occurrence of the period.
 func (p DailyPeriod) NextStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(p.next(p, now))
+	}
 	if p.Contains(now) {
 		cs, _ := p.CurrentStart()
 		ns := cs.AddDate(0, 0, 1)
@@ -80,6 +103,9 @@ func (p DailyPeriod) NextStart() (*time.Time, error) {
 // NextEnd returns the end time of the next occurrence of the period.
 func (p DailyPeriod) NextEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(p.next(p, now))
+	}
 	if p.Contains(now) {
 		ce, _ := p.CurrentEnd()
 		ne := ce.AddDate(0, 0, 1)
@@ -101,6 +127,9 @@ func (p DailyPeriod) NextEnd() (*time.Time, error) {
 // PreviousStart returns the start time of the previous occurrence of the period.
 func (p DailyPeriod) PreviousStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(p.previous(p, now))
+	}
 	if p.Contains(now) {
 		cs, _ := p.CurrentStart()
 		ps := cs.AddDate(0, 0, -1)
@@ -122,6 +151,9 @@ func (p DailyPeriod) PreviousStart() (*time.Time, error) {
 // PreviousEnd returns the end time of the previous occurrence of the period.
 func (p DailyPeriod) PreviousEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(p.previous(p, now))
+	}
 	if p.Contains(now) {
 		ce, _ := p.CurrentEnd()
 		pe := ce.AddDate(0, 0, -1)
@@ -140,6 +172,30 @@ func (p DailyPeriod) PreviousEnd() (*time.Time, error) {
 	return &endTime, err
 }
 
+// wraps reports whether an occurrence of the period ends on the day after its start.
+func (p DailyPeriod) wraps() bool {
+	return p.From.Hour > p.To.Hour || (p.From.Hour == p.To.Hour && !p.Boundary.isClosed())
+}
+
+func (p DailyPeriod) validate() error {
+	if err := p.Boundary.validate(); err != nil {
+		return err
+	}
+	if err := p.Validity.validate(); err != nil {
+		return err
+	}
+	if _, err := parseHour(p.From.Hour); err != nil {
+		return err
+	}
+	_, err := parseHour(p.To.Hour)
+	return err
+}
+
+func (p DailyPeriod) withDefaultBoundary(b Boundary) Period {
+	p.Boundary = p.Boundary.or(b)
+	return p
+}
+
 type TimeEdge struct {
 	Hour string `json:"hour"`
 }
@@ -0,0 +1,137 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Effect declares whether a period adds its times to the schedule or
+// removes them from it.
+//
+// The periods containing a time are layered by priority: the highest
+// priority decides whether the time is included, and when periods of that
+// priority disagree, Exclude wins. The zero value is Include.
+type Effect string
+
+const (
+	// Include periods add their times to the schedule.
+	Include Effect = "include"
+	// Exclude periods punch holes in the periods of the same or a lower priority.
+	Exclude Effect = "exclude"
+)
+
+func (e *Effect) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	effect := Effect(s)
+	if err := effect.validate(); err != nil {
+		return err
+	}
+	*e = effect
+	return nil
+}
+
+func (e Effect) validate() error {
+	switch e {
+	case "", Include, Exclude:
+		return nil
+	default:
+		return fmt.Errorf("invalid effect: %s", string(e))
+	}
+}
+
+// includes reports whether a time contained in the periods with the given
+// labels is included in the schedule.
+func includes(labels []PeriodLabel) bool {
+	ceiling, excluded := math.MinInt, false
+	for _, l := range labels {
+		if l.Effect == Exclude {
+			ceiling, excluded = max(ceiling, l.Priority), true
+		}
+	}
+	for _, l := range labels {
+		if l.Effect != Exclude && (!excluded || l.Priority > ceiling) {
+			return true
+		}
+	}
+	return false
+}
+
+// including returns the first period including t, or math.MaxInt32 if none
+// does, given the members of the weekly and absolute timelines covering t.
+func (s *Schedule) including(t time.Time, weekly, absolute []int32) int32 {
+	visit := func(f func(int32)) {
+		for _, members := range [...][]int32{s.always, weekly, absolute} {
+			for _, i := range members {
+				f(i)
+			}
+		}
+		for _, i := range s.custom {
+			if s.periods[i].Contains(t) {
+				f(i)
+			}
+		}
+	}
+
+	ceiling, excluded := math.MinInt, false
+	visit(func(i int32) {
+		if l := labelOf(s.periods[i]); l.Effect == Exclude {
+			ceiling, excluded = max(ceiling, l.Priority), true
+		}
+	})
+	first := int32(math.MaxInt32)
+	visit(func(i int32) {
+		if l := labelOf(s.periods[i]); l.Effect != Exclude && (!excluded || l.Priority > ceiling) {
+			first = min(first, i)
+		}
+	})
+	return first
+}
+
+// punch cuts out of the occurrences of the including periods, overlapping
+// [from, to], the occurrences of the excluding periods of the same or a
+// higher priority.
+func (s *Schedule) punch(occurrences []Occurrence, from, to time.Time) []Occurrence {
+	lo, hi := from, to
+	for _, occ := range occurrences {
+		if !occ.Unbounded() {
+			lo, hi = minTime(lo, occ.Start), maxTime(hi, occ.End)
+		}
+	}
+	var holes []Occurrence
+	for i, period := range s.periods {
+		o, ok := period.(occurrer)
+		if label := labelOf(period); ok && label.Effect == Exclude {
+			for _, occ := range o.occurrences(lo, hi) {
+				occ.Index, occ.Label = i, label
+				holes = append(holes, occ)
+			}
+		}
+	}
+
+	var result []Occurrence
+	for _, occ := range occurrences {
+		pieces := []Occurrence{occ}
+		for _, hole := range holes {
+			if hole.Label.Priority < occ.Label.Priority {
+				continue
+			}
+			if hole.Unbounded() {
+				pieces = nil
+				break
+			}
+			pieces = subtract(pieces, hole, from, to)
+		}
+		for _, piece := range pieces {
+			if piece.Unbounded() || (!piece.End.Before(from) && !piece.Start.After(to)) {
+				result = append(result, piece)
+			}
+		}
+	}
+	return result
+}
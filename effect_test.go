@@ -0,0 +1,154 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectContains(t *testing.T) {
+	c := Casoncelli{
+		Boundary: HalfOpen,
+		Periods: []Period{
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: DayTimeEdge{Day: time.Saturday, Hour: "22:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "04:00"}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "black friday", Priority: 10, Effect: Exclude}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC)}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "emergency fix", Priority: 20}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 30, 0, 0, time.UTC)}},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	for _, tc := range []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2025, 11, 22, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 11, 29, 22, 30, 0, 0, time.UTC), false},
+		{time.Date(2025, 11, 29, 23, 15, 0, 0, time.UTC), true},
+		{time.Date(2025, 11, 30, 1, 0, 0, 0, time.UTC), false},
+		{time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 11, 28, 12, 0, 0, 0, time.UTC), false},
+	} {
+		assert.Equal(t, tc.expected, c.Contains(tc.at), "Expected the Casoncelli to contain %s: %v", tc.at, tc.expected)
+		assert.Equal(t, tc.expected, s.Contains(tc.at), "Expected the Schedule to contain %s: %v", tc.at, tc.expected)
+	}
+
+	// at the same priority, the exclusion wins
+	c.Periods[1] = OncePeriod{
+		PeriodLabel: PeriodLabel{Effect: Exclude},
+		From:        TimestampEdge{Timestamp: time.Date(2025, 11, 22, 22, 0, 0, 0, time.UTC)},
+		To:          TimestampEdge{Timestamp: time.Date(2025, 11, 23, 4, 0, 0, 0, time.UTC)},
+	}
+	assert.False(t, c.Contains(time.Date(2025, 11, 22, 23, 0, 0, 0, time.UTC)), "Expected the exclusion to win a tie")
+}
+
+func TestEffectOccurrences(t *testing.T) {
+	c := Casoncelli{
+		Boundary: HalfOpen,
+		Periods: []Period{
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: DayTimeEdge{Day: time.Saturday, Hour: "22:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "04:00"}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "black friday", Priority: 10, Effect: Exclude}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC)}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "emergency fix", Priority: 20}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 30, 0, 0, time.UTC)}},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	occurrences := s.Occurrences(time.Date(2025, 11, 29, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC))
+	assert.Len(t, occurrences, 2, "Expected the emergency fix and what is left of the maintenance")
+	assert.Equal(t, "emergency fix", occurrences[0].Label.Name, "Expected the emergency fix first")
+	assert.Equal(t, Occurrence{
+		Start:    time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC),
+		End:      time.Date(2025, 11, 30, 4, 0, 0, 0, time.UTC),
+		Boundary: HalfOpen,
+		Index:    0,
+		Label:    PeriodLabel{Name: "maintenance"},
+	}, occurrences[1], "Expected the maintenance to start at the end of the exclusion")
+
+	next := s.NextMerged(time.Date(2025, 11, 29, 12, 0, 0, 0, time.UTC), 3)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 11, 29, 23, 0, 0, 0, time.UTC),
+		time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 6, 22, 0, 0, 0, time.UTC),
+	}, []time.Time{next[0].Start, next[1].Start, next[2].Start}, "Expected the next starts to skip the exclusion")
+
+	end, ok := s.CurrentEnd(time.Date(2025, 11, 29, 23, 10, 0, 0, time.UTC))
+	assert.True(t, ok, "Expected the end of the emergency fix")
+	assert.Equal(t, time.Date(2025, 11, 29, 23, 30, 0, 0, time.UTC), end, "Expected the maintenance to stay excluded after the fix")
+
+	// an always period is cut within the range of the search
+	always := Casoncelli{Periods: []Period{AlwaysPeriod{}, c.Periods[1]}}
+	s, err = always.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+	end, ok = s.CurrentEnd(time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok, "Expected the always period to end with the exclusion")
+	assert.Equal(t, time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC), end, "Expected the start of the exclusion")
+	assert.Equal(t, []Occurrence{{Index: 0}}, s.Occurrences(time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC), time.Date(2025, 12, 6, 0, 0, 0, 0, time.UTC)), "Expected the always period to stay unbounded far from the exclusion")
+}
+
+func TestEffectOccurrencesBoundaries(t *testing.T) {
+	at := func(hour int) TimestampEdge {
+		return TimestampEdge{Timestamp: time.Date(2025, 11, 29, hour, 0, 0, 0, time.UTC)}
+	}
+	c := Casoncelli{Periods: []Period{
+		OncePeriod{From: at(10), To: at(14), Boundary: Closed},
+		OncePeriod{PeriodLabel: PeriodLabel{Effect: Exclude}, From: at(12), To: at(13), Boundary: Closed},
+	}}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	occurrences := s.Occurrences(at(0).Timestamp, at(23).Timestamp)
+	assert.Len(t, occurrences, 2, "Expected the exclusion to cut the period in two")
+	assert.Equal(t, HalfOpen, occurrences[0].Boundary, "Expected the first piece to exclude the start of the exclusion")
+	assert.Equal(t, OpenClosed, occurrences[1].Boundary, "Expected the second piece to exclude the end of the exclusion")
+
+	merged := s.Merged(at(0).Timestamp, at(23).Timestamp)
+	for hour := 10; hour <= 14; hour++ {
+		for _, ts := range []time.Time{at(hour).Timestamp.Add(-time.Nanosecond), at(hour).Timestamp, at(hour).Timestamp.Add(time.Nanosecond)} {
+			contained := false
+			for _, m := range merged {
+				contained = contained || m.Contains(ts)
+			}
+			assert.Equal(t, s.Contains(ts), contained, "Expected the merged occurrences to match Contains at %s", ts.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+func TestEffectEvaluate(t *testing.T) {
+	c := Casoncelli{
+		Boundary: HalfOpen,
+		Periods: []Period{
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: DayTimeEdge{Day: time.Saturday, Hour: "22:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "04:00"}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "black friday", Priority: 10, Effect: Exclude}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 28, 0, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 30, 2, 0, 0, 0, time.UTC)}},
+			OncePeriod{PeriodLabel: PeriodLabel{Name: "emergency fix", Priority: 20}, From: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 11, 29, 23, 30, 0, 0, time.UTC)}},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	var ts []time.Time
+	for at := time.Date(2025, 11, 22, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)); at = at.Add(10 * time.Minute) {
+		ts = append(ts, at)
+	}
+	for i, m := range s.Evaluate(ts) {
+		assert.Equal(t, c.Contains(ts[i]), m.Contained, "Expected the membership at %s to match Contains", ts[i])
+		active := s.Active(ts[i])
+		assert.Equal(t, len(active) > 0, m.Contained, "Expected the membership at %s to match Active", ts[i])
+		if m.Contained {
+			assert.Equal(t, active[0].Index, m.Index, "Expected the period including %s", ts[i])
+		}
+	}
+}
+
+func TestEffectValidate(t *testing.T) {
+	c := Casoncelli{Periods: []Period{AlwaysPeriod{PeriodLabel: PeriodLabel{Effect: "deny"}}}}
+	assert.EqualError(t, c.Validate(), "period 0: invalid effect: deny", "Expected an unknown effect to be refused")
+
+	err := json.Unmarshal([]byte(`{"periods": [{"type": "always", "effect": "deny"}]}`), &c)
+	assert.EqualError(t, err, "invalid effect: deny", "Expected an unknown effect to be refused")
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"periods": [{"type": "always", "priority": 5, "effect": "exclude"}]}`), &c), "Expected the effect to be unmarshaled")
+	assert.Equal(t, PeriodLabel{Priority: 5, Effect: Exclude}, labelOf(c.Periods[0]), "Expected the priority and the effect")
+}
@@ -0,0 +1,861 @@
+package casoncelli
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultProductID is the PRODID of the calendars exported by an ICalendar
+// without a ProductID.
+const DefaultProductID = "-//valmoz//casoncelli//EN"
+
+// icalTimezoneYears is how many years of time zone transitions are exported.
+const icalTimezoneYears = 10
+
+const (
+	icalDateTimeLayout    = "20060102T150405"
+	icalDateTimeUTCLayout = "20060102T150405Z"
+	icalDateLayout        = "20060102"
+)
+
+// ICalendar converts schedules to and from RFC 5545 iCalendar files.
+//
+// Daily and weekly periods are exported as events recurring from the first
+// occurrence after Since, in Location; once periods as single events. An
+// AlwaysPeriod is an all-day event recurring every day, while never periods
+// and the periods not defined by this package are left out. iCalendar
+// events include their start and not their end, regardless of the Boundary
+// of the periods.
+type ICalendar struct {
+	// ProductID is the PRODID of the exported calendars, DefaultProductID if empty.
+	ProductID string
+	// Location is the time zone of the recurring periods, and of the dates and
+	// floating times of the imported events, time.Local if nil. Having no
+	// name calendar applications know, time.Local is exported in UTC.
+	Location *time.Location
+	// Since is when the recurring periods start, the current time if zero.
+	Since time.Time
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+}
+
+// Export writes the periods of c as a VCALENDAR. The excluding periods
+// are skipped, and so are the holes they punch in the other periods. The
+// recurring periods with a validity recur from their first whole occurrence
+// within it, until its end.
+func (ic ICalendar) Export(w io.Writer, c Casoncelli) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	loc := ic.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	now := clockOrSystem(ic.Clock).Now()
+	since := ic.Since
+	if since.IsZero() {
+		since = now
+	}
+	since = since.In(loc)
+	productID := ic.ProductID
+	if productID == "" {
+		productID = DefaultProductID
+	}
+
+	out := &icalWriter{w: bufio.NewWriter(w)}
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:" + productID)
+	out.line("CALSCALE:GREGORIAN")
+	recurring := false
+	for _, period := range c.Periods {
+		switch period.(type) {
+		case DailyPeriod, WeeklyPeriod:
+			recurring = true
+		}
+	}
+	utc := loc == time.UTC || loc == time.Local
+	if recurring && !utc {
+		out.timezone(loc, since.Year(), since.Year()+icalTimezoneYears)
+	}
+
+	uids := map[string]int{}
+	stamp := now.UTC().Format(icalDateTimeUTCLayout)
+	for _, period := range c.Periods {
+		period = c.resolve(period)
+		if labelOf(period).Effect == Exclude {
+			continue
+		}
+		var occ Occurrence
+		var rule string
+		ok := true
+		switch p := period.(type) {
+		case DailyPeriod:
+			v := p.Validity
+			p.Validity = Validity{}
+			occ, rule, ok = icalFirst(p, v, since)
+			rule = "FREQ=DAILY" + rule
+		case WeeklyPeriod:
+			v := p.Validity
+			p.Validity = Validity{}
+			occ, rule, ok = icalFirst(p, v, since)
+			day := p.From.Day
+			if utc {
+				day = occ.Start.UTC().Weekday()
+			}
+			rule = "FREQ=WEEKLY;BYDAY=" + icalDays[day] + rule
+		case OncePeriod:
+			occ = Occurrence{Start: p.From.Timestamp, End: p.To.Timestamp}
+		case AlwaysPeriod:
+			rule = "FREQ=DAILY"
+		default:
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		label := labelOf(period)
+		uid := label.ID
+		if uid == "" {
+			uid = icalUID(period)
+		}
+		if uids[uid]++; uids[uid] > 1 {
+			uid = fmt.Sprintf("%s-%d", uid, uids[uid])
+		}
+		out.line("BEGIN:VEVENT")
+		out.line("UID:" + uid + "@casoncelli")
+		out.line("DTSTAMP:" + stamp)
+		switch {
+		case occ.Unbounded():
+			day := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, time.UTC)
+			out.line("DTSTART;VALUE=DATE:" + day.Format(icalDateLayout))
+			out.line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format(icalDateLayout))
+		case rule == "" || utc:
+			out.line("DTSTART:" + occ.Start.UTC().Format(icalDateTimeUTCLayout))
+			out.line("DTEND:" + occ.End.UTC().Format(icalDateTimeUTCLayout))
+		default:
+			out.line("DTSTART;TZID=" + icalParam(loc.String()) + ":" + occ.Start.Format(icalDateTimeLayout))
+			out.line("DTEND;TZID=" + icalParam(loc.String()) + ":" + occ.End.Format(icalDateTimeLayout))
+		}
+		if rule != "" {
+			out.line("RRULE:" + rule)
+		}
+		if label.Name != "" {
+			out.line("SUMMARY:" + icalText(label.Name))
+		}
+		if label.Description != "" {
+			out.line("DESCRIPTION:" + icalText(label.Description))
+		}
+		out.line("END:VEVENT")
+	}
+	out.line("END:VCALENDAR")
+	return out.flush()
+}
+
+// icalDays are the iCalendar names of the days of the week.
+var icalDays = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+// icalUID returns an identifier of a period without an id which is stable
+// across exports.
+func icalUID(p Period) string {
+	data, _ := json.Marshal(p)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// icalText escapes a TEXT value.
+func icalText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalParam quotes a parameter value when needed.
+func icalParam(s string) string {
+	if strings.ContainsAny(s, `:;,`) {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// icalFirst returns the first occurrence of the recurring period p to export
+// from since, within the validity v, along with the UNTIL part of its rule.
+// It reports false if no occurrence is left.
+func icalFirst(p occurrer, v Validity, since time.Time) (Occurrence, string, bool) {
+	if v.ValidFrom != nil && v.ValidFrom.Timestamp.After(since) {
+		since = v.ValidFrom.Timestamp.In(since.Location())
+	}
+	var first Occurrence
+	for _, occ := range p.occurrences(since, since.Add(recurringLookaround)) {
+		if v.ValidFrom == nil || !occ.Start.Before(v.ValidFrom.Timestamp) {
+			first = occ
+			break
+		}
+	}
+	if v.ValidUntil == nil {
+		return first, "", true
+	}
+	if !first.Start.Before(v.ValidUntil.Timestamp) {
+		return Occurrence{}, "", false
+	}
+	return first, ";UNTIL=" + v.ValidUntil.Timestamp.Add(-time.Second).UTC().Format(icalDateTimeUTCLayout), true
+}
+
+// icalWriter writes content lines, folded at 75 octets.
+type icalWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *icalWriter) line(s string) {
+	for len(s) > 75 {
+		cut := 75
+		for !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.write(s[:cut] + "\r\n")
+		// the continuation lines start with a space, which counts
+		s = " " + s[cut:]
+	}
+	w.write(s + "\r\n")
+}
+
+func (w *icalWriter) write(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+func (w *icalWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// timezone writes a VTIMEZONE describing the transitions of loc between the
+// years from and to.
+func (w *icalWriter) timezone(loc *time.Location, from, to int) {
+	offset := func(t time.Time) int {
+		_, o := t.In(loc).Zone()
+		return o
+	}
+	utcOffset := func(seconds int) string {
+		sign := "+"
+		if seconds < 0 {
+			sign, seconds = "-", -seconds
+		}
+		return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+	}
+
+	w.line("BEGIN:VTIMEZONE")
+	w.line("TZID:" + loc.String())
+	t := time.Date(from, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(to, time.January, 1, 0, 0, 0, 0, time.UTC)
+	component := func(onset time.Time, from, to int) {
+		kind := "STANDARD"
+		if onset.In(loc).IsDST() {
+			kind = "DAYLIGHT"
+		}
+		name, _ := onset.In(loc).Zone()
+		w.line("BEGIN:" + kind)
+		// the onset is in the local time before it
+		w.line("DTSTART:" + onset.Add(time.Duration(from)*time.Second).UTC().Format(icalDateTimeLayout))
+		w.line("TZOFFSETFROM:" + utcOffset(from))
+		w.line("TZOFFSETTO:" + utcOffset(to))
+		w.line("TZNAME:" + name)
+		w.line("END:" + kind)
+	}
+	// the offset in force at the start, in case there are no transitions
+	component(t, offset(t), offset(t))
+	for t.Before(end) {
+		next := t.Add(24 * time.Hour)
+		if offset(next) == offset(t) {
+			t = next
+			continue
+		}
+		// find the exact second of the transition
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2)
+			if offset(mid) == offset(lo) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+		component(hi.Truncate(time.Second), offset(lo), offset(hi))
+		t = next
+	}
+	w.line("END:VTIMEZONE")
+}
+
+// icalMaxExpanded is the most occurrences of a bounded recurring event that
+// are imported, as once periods.
+const icalMaxExpanded = 1000
+
+// ICalendarIssue is an event of an iCalendar file that the periods of this
+// package cannot represent exactly.
+type ICalendarIssue struct {
+	// UID and Summary identify the event.
+	UID     string
+	Summary string
+	// Reason tells what cannot be represented.
+	Reason string
+	// Imported reports whether the event was imported anyway, without the
+	// parts described by Reason.
+	Imported bool
+}
+
+func (i ICalendarIssue) String() string {
+	name := i.Summary
+	if name == "" {
+		name = i.UID
+	}
+	if i.Imported {
+		return fmt.Sprintf("event %q imported partially: %s", name, i.Reason)
+	}
+	return fmt.Sprintf("event %q not imported: %s", name, i.Reason)
+}
+
+// Import reads the events of a VCALENDAR as the periods of a Casoncelli,
+// along with the issues of the events that cannot be represented exactly.
+//
+// Single events, and the dates of RDATE, become once periods. Events
+// recurring forever every day or every week become daily and weekly periods,
+// with the times they have in Location, valid from DTSTART; the ones ending
+// after a COUNT or an UNTIL become once periods instead, one per occurrence,
+// without the ones of EXDATE. All-day events last from midnight to midnight
+// in Location, and the ones recurring every day become an AlwaysPeriod,
+// which has no start. Like the iCalendar events, the periods are HalfOpen.
+func (ic ICalendar) Import(r io.Reader) (Casoncelli, []ICalendarIssue, error) {
+	events, err := icalEvents(r)
+	if err != nil {
+		return Casoncelli{}, nil, err
+	}
+	loc := ic.Location
+	if loc == nil {
+		loc = time.Local
+	}
+
+	c := Casoncelli{Periods: []Period{}, Boundary: HalfOpen}
+	var issues []ICalendarIssue
+	for _, e := range events {
+		uid, _ := e.get("UID")
+		summary, _ := e.get("SUMMARY")
+		issue := ICalendarIssue{UID: uid.Value, Summary: icalUnescape(summary.Value)}
+		periods, notes, err := e.periods(loc)
+		if err != nil {
+			issue.Reason = err.Error()
+			issues = append(issues, issue)
+			continue
+		}
+		c.Periods = append(c.Periods, periods...)
+		for _, note := range notes {
+			issue.Reason, issue.Imported = note, true
+			issues = append(issues, issue)
+		}
+	}
+	return c, issues, nil
+}
+
+// icalProperty is a content line of an iCalendar file.
+type icalProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// icalEvent holds the properties of a VEVENT by name.
+type icalEvent map[string][]icalProperty
+
+// get returns the first property named name.
+func (e icalEvent) get(name string) (icalProperty, bool) {
+	if len(e[name]) == 0 {
+		return icalProperty{}, false
+	}
+	return e[name][0], true
+}
+
+// icalEvents returns the VEVENTs of the VCALENDAR read from r.
+func icalEvents(r io.Reader) ([]icalEvent, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case line == "":
+		case line[0] == ' ' || line[0] == '\t':
+			if len(lines) == 0 {
+				return nil, errors.New("ical: continuation line at the start of the file")
+			}
+			lines[len(lines)-1] += line[1:]
+		default:
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	var events []icalEvent
+	var event icalEvent
+	var components []string
+	for i, line := range lines {
+		p, err := parseICalProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("ical: content line %d: %w", i+1, err)
+		}
+		if i == 0 && (p.Name != "BEGIN" || !strings.EqualFold(p.Value, "VCALENDAR")) {
+			return nil, errors.New("ical: not a VCALENDAR")
+		}
+		switch p.Name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(p.Value))
+			if len(components) == 2 && components[1] == "VEVENT" {
+				event = icalEvent{}
+			}
+		case "END":
+			if len(components) == 0 || components[len(components)-1] != strings.ToUpper(p.Value) {
+				return nil, fmt.Errorf("ical: content line %d: unexpected END:%s", i+1, p.Value)
+			}
+			if len(components) == 2 && event != nil {
+				events = append(events, event)
+				event = nil
+			}
+			components = components[:len(components)-1]
+		default:
+			// the properties of the components nested in the events, like VALARM, are not needed
+			if len(components) == 2 && event != nil {
+				event[p.Name] = append(event[p.Name], p)
+			}
+		}
+	}
+	if len(components) > 0 {
+		return nil, fmt.Errorf("ical: missing END:%s", components[len(components)-1])
+	}
+	return events, nil
+}
+
+// parseICalProperty parses an unfolded content line, like
+// DTSTART;TZID=Europe/Rome:20250507T230000.
+func parseICalProperty(line string) (icalProperty, error) {
+	end := strings.IndexAny(line, ";:")
+	if end <= 0 {
+		return icalProperty{}, fmt.Errorf("malformed content line %q", line)
+	}
+	p := icalProperty{Name: strings.ToUpper(line[:end]), Params: map[string]string{}}
+	rest := line[end:]
+	for rest[0] == ';' {
+		rest = rest[1:]
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return icalProperty{}, fmt.Errorf("malformed parameter in %q", line)
+		}
+		name := strings.ToUpper(rest[:eq])
+		rest = rest[eq+1:]
+		if strings.HasPrefix(rest, `"`) {
+			closing := strings.IndexByte(rest[1:], '"')
+			if closing < 0 {
+				return icalProperty{}, fmt.Errorf("unterminated quoted parameter in %q", line)
+			}
+			p.Params[name], rest = rest[1:closing+1], rest[closing+2:]
+		} else {
+			stop := strings.IndexAny(rest, ";:")
+			if stop < 0 {
+				return icalProperty{}, fmt.Errorf("missing value in %q", line)
+			}
+			p.Params[name], rest = rest[:stop], rest[stop:]
+		}
+		if rest == "" {
+			return icalProperty{}, fmt.Errorf("missing value in %q", line)
+		}
+	}
+	if rest[0] != ':' {
+		return icalProperty{}, fmt.Errorf("malformed content line %q", line)
+	}
+	p.Value = rest[1:]
+	return p, nil
+}
+
+// icalUnescape returns the string of a TEXT value.
+func icalUnescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' || s[i] == 'N' {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// icalTimes parses the DATE or DATE-TIME values of p, reporting whether
+// they are dates. Dates and floating times are in loc.
+func icalTimes(p icalProperty, loc *time.Location) ([]time.Time, bool, error) {
+	if value := p.Params["VALUE"]; value != "" && value != "DATE" && value != "DATE-TIME" {
+		return nil, false, fmt.Errorf("%s values of %s are not supported", value, p.Name)
+	}
+	if tzid := p.Params["TZID"]; tzid != "" {
+		zone, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err != nil {
+			return nil, false, fmt.Errorf("unknown time zone %q", tzid)
+		}
+		loc = zone
+	}
+	date := p.Params["VALUE"] == "DATE"
+	var times []time.Time
+	for _, value := range strings.Split(p.Value, ",") {
+		var t time.Time
+		var err error
+		switch {
+		case date || len(value) == len(icalDateLayout):
+			date = true
+			t, err = time.ParseInLocation(icalDateLayout, value, loc)
+		case strings.HasSuffix(value, "Z"):
+			t, err = time.Parse(icalDateTimeUTCLayout, value)
+		default:
+			t, err = time.ParseInLocation(icalDateTimeLayout, value, loc)
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid %s %q", p.Name, value)
+		}
+		times = append(times, t)
+	}
+	return times, date, nil
+}
+
+var icalDurationPattern = regexp.MustCompile(`^\+?P(?:(\d+)W|(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?)$`)
+
+// parseICalDuration parses a DURATION value, returning its nominal days
+// apart, as they last as long as the wall clock says.
+func parseICalDuration(s string) (int, time.Duration, error) {
+	m := icalDurationPattern.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return 0, 0, fmt.Errorf("invalid DURATION %q", s)
+	}
+	n := func(i int) int {
+		v, _ := strconv.Atoi(m[i])
+		return v
+	}
+	days := n(1)*7 + n(2)
+	d := time.Duration(n(3))*time.Hour + time.Duration(n(4))*time.Minute + time.Duration(n(5))*time.Second
+	return days, d, nil
+}
+
+// icalRule is a RRULE made of the parts that the periods can represent.
+type icalRule struct {
+	Freq      string
+	Interval  int
+	Count     int
+	Until     time.Time
+	Days      []time.Weekday
+	WeekStart time.Weekday
+}
+
+// bounded reports whether the rule ends.
+func (r icalRule) bounded() bool {
+	return r.Count > 0 || !r.Until.IsZero()
+}
+
+// parseICalRule parses the RRULE of an event starting at start.
+func parseICalRule(s string, start time.Time) (icalRule, error) {
+	r := icalRule{Interval: 1, WeekStart: time.Monday}
+	for _, part := range strings.Split(s, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+			if r.Freq != "DAILY" && r.Freq != "WEEKLY" {
+				return icalRule{}, fmt.Errorf("FREQ=%s is not supported", value)
+			}
+		case "INTERVAL":
+			r.Interval, err = strconv.Atoi(value)
+			if err == nil && r.Interval < 1 {
+				err = errors.New("not positive")
+			}
+		case "COUNT":
+			r.Count, err = strconv.Atoi(value)
+			if err == nil && r.Count < 1 {
+				err = errors.New("not positive")
+			}
+		case "UNTIL":
+			var until []time.Time
+			until, _, err = icalTimes(icalProperty{Name: "UNTIL", Value: value}, start.Location())
+			if err == nil {
+				r.Until = until[0]
+			}
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				i := slices.Index(icalDays[:], strings.ToUpper(day))
+				if i < 0 {
+					return icalRule{}, fmt.Errorf("BYDAY=%s is not supported", value)
+				}
+				r.Days = append(r.Days, time.Weekday(i))
+			}
+		case "WKST":
+			i := slices.Index(icalDays[:], strings.ToUpper(value))
+			if i < 0 {
+				err = errors.New("unknown day")
+			}
+			r.WeekStart = time.Weekday(i)
+		default:
+			return icalRule{}, fmt.Errorf("%s is not supported", part)
+		}
+		if err != nil {
+			return icalRule{}, fmt.Errorf("invalid %s in RRULE: %w", part, err)
+		}
+	}
+	if r.Freq == "" {
+		return icalRule{}, errors.New("missing FREQ in RRULE")
+	}
+	if r.Freq == "WEEKLY" && len(r.Days) == 0 {
+		r.Days = []time.Weekday{start.Weekday()}
+	}
+	return r, nil
+}
+
+// starts returns the starts of the occurrences of the bounded rule of an
+// event starting at start, reporting whether there are no more than limit.
+func (r icalRule) starts(start time.Time, limit int) ([]time.Time, bool) {
+	// the start is always the first occurrence, even when the rule does not match it
+	starts := []time.Time{start}
+	done := func(t time.Time) bool {
+		return (r.Count > 0 && len(starts) >= r.Count) || (!r.Until.IsZero() && t.After(r.Until)) || len(starts) > limit
+	}
+	year, month, day := start.Date()
+	clock := func(days int) time.Time {
+		return time.Date(year, month, day+days, start.Hour(), start.Minute(), start.Second(), start.Nanosecond(), start.Location())
+	}
+
+	// the days of the period of the rule from the start, like a week
+	stride, span := r.Interval, 1
+	first := 0
+	if r.Freq == "WEEKLY" {
+		stride, span = 7*r.Interval, 7
+		first = -((int(start.Weekday()) - int(r.WeekStart) + 7) % 7)
+	}
+	for base := first; ; base += stride {
+		// a rule matching no day, like every seventh day on Mondays from a Tuesday,
+		// has no occurrence after the start
+		if base > 7*(limit+1)*stride {
+			return starts, true
+		}
+		for offset := range span {
+			t := clock(base + offset)
+			if !t.After(start) || (len(r.Days) > 0 && !slices.Contains(r.Days, t.Weekday())) {
+				continue
+			}
+			if done(t) {
+				return starts, len(starts) <= limit
+			}
+			starts = append(starts, t)
+		}
+	}
+}
+
+// periods returns the periods of the event, with notes on what they leave out.
+func (e icalEvent) periods(loc *time.Location) ([]Period, []string, error) {
+	if status, _ := e.get("STATUS"); strings.EqualFold(status.Value, "CANCELLED") {
+		return nil, nil, nil
+	}
+	if _, ok := e.get("RECURRENCE-ID"); ok {
+		return nil, nil, errors.New("changing an occurrence of another event is not supported")
+	}
+	dtstart, ok := e.get("DTSTART")
+	if !ok {
+		return nil, nil, errors.New("missing DTSTART")
+	}
+	times, allDay, err := icalTimes(dtstart, loc)
+	if err != nil {
+		return nil, nil, err
+	}
+	start := times[0]
+
+	// the end of an occurrence starting at t
+	end := func(t time.Time) time.Time {
+		if allDay {
+			return t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	if dtend, ok := e.get("DTEND"); ok {
+		times, _, err := icalTimes(dtend, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		if times[0].Before(start) {
+			return nil, nil, errors.New("DTEND is before DTSTART")
+		}
+		days := icalDaysBetween(start, times[0])
+		d := times[0].Sub(start)
+		end = func(t time.Time) time.Time {
+			if allDay {
+				return t.AddDate(0, 0, days)
+			}
+			return t.Add(d)
+		}
+	} else if duration, ok := e.get("DURATION"); ok {
+		days, d, err := parseICalDuration(duration.Value)
+		if err != nil {
+			return nil, nil, err
+		}
+		end = func(t time.Time) time.Time {
+			return t.AddDate(0, 0, days).Add(d)
+		}
+	}
+
+	summary, _ := e.get("SUMMARY")
+	description, _ := e.get("DESCRIPTION")
+	label := PeriodLabel{Name: icalUnescape(summary.Value), Description: icalUnescape(description.Value)}
+	once := func(t time.Time) Period {
+		return OncePeriod{PeriodLabel: label, From: TimestampEdge{Timestamp: t}, To: TimestampEdge{Timestamp: end(t)}}
+	}
+
+	var extra []Period
+	for _, rdate := range e["RDATE"] {
+		times, _, err := icalTimes(rdate, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, t := range times {
+			extra = append(extra, once(t))
+		}
+	}
+	var exdates []time.Time
+	for _, exdate := range e["EXDATE"] {
+		times, _, err := icalTimes(exdate, loc)
+		if err != nil {
+			return nil, nil, err
+		}
+		exdates = append(exdates, times...)
+	}
+
+	rrule, ok := e.get("RRULE")
+	if !ok {
+		return append([]Period{once(start)}, extra...), nil, nil
+	}
+	if len(e["RRULE"]) > 1 {
+		return nil, nil, errors.New("several RRULEs are not supported")
+	}
+	rule, err := parseICalRule(rrule.Value, start)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if rule.bounded() {
+		starts, ok := rule.starts(start, icalMaxExpanded)
+		if !ok {
+			return nil, nil, fmt.Errorf("more than %d occurrences", icalMaxExpanded)
+		}
+		var periods []Period
+		for _, t := range starts {
+			excluded := slices.ContainsFunc(exdates, func(x time.Time) bool {
+				return x.Equal(t) || (allDay && x.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, x.Location())))
+			})
+			if !excluded {
+				periods = append(periods, once(t))
+			}
+		}
+		return append(periods, extra...), nil, nil
+	}
+	if rule.Interval > 1 {
+		return nil, nil, fmt.Errorf("repeating forever every %d %s is not supported", rule.Interval, map[string]string{"DAILY": "days", "WEEKLY": "weeks"}[rule.Freq])
+	}
+
+	var notes []string
+	if len(exdates) > 0 {
+		notes = append(notes, fmt.Sprintf("the %d dates of EXDATE are ignored", len(exdates)))
+	}
+	local := start.In(loc)
+	if !allDay && !icalSameClock(start.Location(), loc, start) {
+		notes = append(notes, fmt.Sprintf("the times follow %s, whose daylight saving time differs from %s", loc, start.Location()))
+	}
+	if local.Second() != 0 || local.Nanosecond() != 0 {
+		notes = append(notes, "the times are truncated to the minute")
+	}
+
+	// the occurrences on the wall clock of a week without daylight saving time
+	d := end(start).Sub(start)
+	if allDay {
+		d = time.Duration(icalDaysBetween(start, end(start))) * 24 * time.Hour
+	}
+	if d == 0 {
+		// a half-open period from and to the same time would span the whole cycle
+		return nil, nil, errors.New("repeating forever without DTEND or DURATION is not supported")
+	}
+	days := rule.Days
+	if len(days) == 7 {
+		days = nil
+	}
+	// the recurrence starts on DTSTART
+	validity := Validity{ValidFrom: &TimestampEdge{Timestamp: local}}
+	var periods []Period
+	switch {
+	case d >= 7*24*time.Hour || (len(days) == 0 && d >= 24*time.Hour):
+		periods = []Period{AlwaysPeriod{PeriodLabel: label}}
+		notes = append(notes, "the period is in effect before DTSTART too")
+	case len(days) == 0:
+		from := time.Date(2000, 1, 1, local.Hour(), local.Minute(), 0, 0, time.UTC)
+		periods = []Period{DailyPeriod{
+			PeriodLabel: label,
+			Validity:    validity,
+			From:        TimeEdge{Hour: from.Format("15:04")},
+			To:          TimeEdge{Hour: from.Add(d).Format("15:04")},
+		}}
+	default:
+		for _, day := range days {
+			// January 2, 2000 is a Sunday
+			from := time.Date(2000, 1, 2+int(day), local.Hour(), local.Minute(), 0, 0, time.UTC)
+			to := from.Add(d)
+			periods = append(periods, WeeklyPeriod{
+				PeriodLabel: label,
+				Validity:    validity,
+				From:        DayTimeEdge{Day: from.Weekday(), Hour: from.Format("15:04")},
+				To:          DayTimeEdge{Day: to.Weekday(), Hour: to.Format("15:04")},
+			})
+		}
+	}
+	return append(periods, extra...), notes, nil
+}
+
+// icalDaysBetween returns the days between the dates of from and to.
+func icalDaysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a) / (24 * time.Hour))
+}
+
+// icalSameClock reports whether the wall clocks of a and b stay the same
+// distance apart during the year from t.
+func icalSameClock(a, b *time.Location, t time.Time) bool {
+	difference := func(t time.Time) int {
+		_, x := t.In(a).Zone()
+		_, y := t.In(b).Zone()
+		return x - y
+	}
+	for month := 1; month <= 12; month++ {
+		if difference(t.AddDate(0, month, 0)) != difference(t) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,304 @@
+package casoncelli
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICalendarExportUTC(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance", Description: "update indexes; reboot, maybe"}, From: TimeEdge{Hour: "23:00"}, To: TimeEdge{Hour: "01:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		OncePeriod{PeriodLabel: PeriodLabel{Name: "migration"}, From: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		AlwaysPeriod{},
+		NeverPeriod{},
+	}}
+	now := time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	err := ICalendar{Location: time.UTC, Clock: newFakeClock(now)}.Export(&b, c)
+	assert.NoError(t, err, "Expected the calendar to be exported")
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	assert.Equal(t, []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:" + DefaultProductID, "CALSCALE:GREGORIAN"}, lines[:4], "Expected the calendar header")
+	assert.Equal(t, "END:VCALENDAR", lines[len(lines)-1], "Expected the calendar to be closed")
+	assert.NotContains(t, b.String(), "VTIMEZONE", "Expected no time zone in UTC")
+	assert.Equal(t, 4, strings.Count(b.String(), "BEGIN:VEVENT"), "Expected the never period to be left out")
+
+	for _, expected := range []string{
+		"DTSTAMP:20250507T120000Z",
+		"DTSTART:20250507T230000Z\r\nDTEND:20250508T013000Z\r\nRRULE:FREQ=DAILY\r\nSUMMARY:maintenance\r\n",
+		`DESCRIPTION:update indexes\; reboot\, maybe`,
+		"DTSTART:20250509T160000Z\r\nDTEND:20250512T080000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=FR\r\nSUMMARY:freeze\r\n",
+		"DTSTART:20250601T100000Z\r\nDTEND:20250601T120000Z\r\nSUMMARY:migration\r\nEND:VEVENT",
+		"DTSTART;VALUE=DATE:20250507\r\nDTEND;VALUE=DATE:20250508\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT",
+	} {
+		assert.Contains(t, b.String(), expected, "Expected the calendar to contain %q", expected)
+	}
+}
+
+func TestICalendarExportLocal(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "00:30"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	}}
+	since := time.Date(2025, 5, 7, 12, 0, 0, 0, time.Local)
+	var b strings.Builder
+	err := ICalendar{Location: time.Local, Since: since}.Export(&b, c)
+	assert.NoError(t, err, "Expected the calendar to be exported")
+
+	start := time.Date(2025, 5, 9, 0, 30, 0, 0, time.Local).UTC()
+	assert.NotContains(t, b.String(), "TZID", "Expected no time zone without a name")
+	assert.Contains(t, b.String(), "DTSTART:"+start.Format(icalDateTimeUTCLayout)+"\r\n", "Expected the start in UTC")
+	assert.Contains(t, b.String(), "RRULE:FREQ=WEEKLY;BYDAY="+icalDays[start.Weekday()]+"\r\n", "Expected the day of the start in UTC")
+}
+
+func TestICalendarExportStable(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance", Description: "update indexes; reboot, maybe"}, From: TimeEdge{Hour: "23:00"}, To: TimeEdge{Hour: "01:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		OncePeriod{PeriodLabel: PeriodLabel{Name: "migration"}, From: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		AlwaysPeriod{},
+		NeverPeriod{},
+	}}
+	export := func(since time.Time) string {
+		var b strings.Builder
+		err := ICalendar{Location: time.UTC, Since: since}.Export(&b, c)
+		assert.NoError(t, err, "Expected the calendar to be exported")
+		var uids []string
+		for _, line := range strings.Split(b.String(), "\r\n") {
+			if strings.HasPrefix(line, "UID:") {
+				uids = append(uids, line)
+			}
+		}
+		return strings.Join(uids, "\n")
+	}
+	first := export(time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC))
+	assert.Equal(t, first, export(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)), "Expected the UIDs to be stable")
+
+	c = Casoncelli{Periods: []Period{AlwaysPeriod{}, AlwaysPeriod{}, OncePeriod{
+		PeriodLabel: PeriodLabel{ID: "db-upgrade"},
+		From:        TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)},
+		To:          TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)},
+	}}}
+	var b strings.Builder
+	assert.NoError(t, ICalendar{}.Export(&b, c), "Expected the calendar to be exported")
+	assert.Contains(t, b.String(), "UID:db-upgrade@casoncelli\r\n", "Expected the UID to be the id of the period")
+	uid := icalUID(AlwaysPeriod{})
+	assert.Contains(t, b.String(), "UID:"+uid+"@casoncelli\r\n", "Expected the UID of the first period")
+	assert.Contains(t, b.String(), "UID:"+uid+"-2@casoncelli\r\n", "Expected the UID of the duplicate to differ")
+}
+
+func TestICalendarExportTimezone(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance", Description: "update indexes; reboot, maybe"}, From: TimeEdge{Hour: "23:00"}, To: TimeEdge{Hour: "01:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		OncePeriod{PeriodLabel: PeriodLabel{Name: "migration"}, From: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		AlwaysPeriod{},
+		NeverPeriod{},
+	}}
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err, "Expected the location to be loaded")
+	var b strings.Builder
+	since := time.Date(2025, 5, 7, 12, 0, 0, 0, rome)
+	err = ICalendar{Location: rome, Since: since, ProductID: "-//example//ops//EN"}.Export(&b, c)
+	assert.NoError(t, err, "Expected the calendar to be exported")
+
+	for _, expected := range []string{
+		"PRODID:-//example//ops//EN",
+		"BEGIN:VTIMEZONE\r\nTZID:Europe/Rome\r\nBEGIN:STANDARD\r\nDTSTART:20250101T010000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD\r\n",
+		"BEGIN:DAYLIGHT\r\nDTSTART:20250330T020000\r\nTZOFFSETFROM:+0100\r\nTZOFFSETTO:+0200\r\nTZNAME:CEST\r\nEND:DAYLIGHT\r\n",
+		"BEGIN:STANDARD\r\nDTSTART:20251026T030000\r\nTZOFFSETFROM:+0200\r\nTZOFFSETTO:+0100\r\nTZNAME:CET\r\nEND:STANDARD\r\n",
+		"DTSTART;TZID=Europe/Rome:20250507T230000\r\nDTEND;TZID=Europe/Rome:20250508T013000\r\nRRULE:FREQ=DAILY\r\n",
+		"DTSTART;TZID=Europe/Rome:20250509T160000\r\nDTEND;TZID=Europe/Rome:20250512T080000\r\nRRULE:FREQ=WEEKLY;BYDAY=FR\r\n",
+		"DTSTART:20250601T100000Z\r\nDTEND:20250601T120000Z\r\n",
+	} {
+		assert.Contains(t, b.String(), expected, "Expected the calendar to contain %q", expected)
+	}
+	assert.Equal(t, icalTimezoneYears, strings.Count(b.String(), "BEGIN:DAYLIGHT"), "Expected a transition to daylight saving time a year")
+}
+
+func TestICalendarExportFolding(t *testing.T) {
+	description := strings.Repeat("è", 100) + "\nend"
+	c := Casoncelli{Periods: []Period{OncePeriod{
+		PeriodLabel: PeriodLabel{Name: "long", Description: description},
+		From:        TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)},
+		To:          TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)},
+	}}}
+	var b strings.Builder
+	assert.NoError(t, ICalendar{}.Export(&b, c), "Expected the calendar to be exported")
+
+	var unfolded strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, "Expected the line %q to be folded", line)
+		assert.True(t, strings.ToValidUTF8(line, "") == line, "Expected the line %q to be valid UTF-8", line)
+		if strings.HasPrefix(line, " ") {
+			unfolded.WriteString(line[1:])
+		} else {
+			unfolded.WriteString("\n" + line)
+		}
+	}
+	assert.Contains(t, unfolded.String(), "\nDESCRIPTION:"+strings.Repeat("è", 100)+`\nend`+"\n", "Expected the description to unfold")
+}
+
+func TestICalendarExportInvalid(t *testing.T) {
+	c := Casoncelli{Periods: []Period{DailyPeriod{From: TimeEdge{Hour: "25:00"}, To: TimeEdge{Hour: "26:00"}}}}
+	var b strings.Builder
+	assert.Error(t, ICalendar{}.Export(&b, c), "Expected an invalid schedule to be rejected")
+	assert.Empty(t, b.String(), "Expected nothing to be written")
+}
+
+func icalendarImport(t *testing.T, loc *time.Location, events ...string) (Casoncelli, []ICalendarIssue) {
+	ics := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//vendor//EN\r\n" + strings.Join(events, "") + "END:VCALENDAR\r\n"
+	c, issues, err := ICalendar{Location: loc}.Import(strings.NewReader(ics))
+	assert.NoError(t, err, "Expected the calendar to be imported")
+	return c, issues
+}
+
+func TestICalendarImportRoundTrip(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance", Description: "update indexes; reboot, maybe"}, From: TimeEdge{Hour: "23:00"}, To: TimeEdge{Hour: "01:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		OncePeriod{PeriodLabel: PeriodLabel{Name: "migration"}, From: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)}, To: TimestampEdge{Timestamp: time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)}},
+		AlwaysPeriod{},
+		NeverPeriod{},
+	}}
+	var b strings.Builder
+	err := ICalendar{Location: time.UTC, Since: time.Date(2025, 5, 7, 12, 0, 0, 0, time.UTC)}.Export(&b, c)
+	assert.NoError(t, err, "Expected the calendar to be exported")
+
+	imported, issues, err := ICalendar{Location: time.UTC}.Import(strings.NewReader(b.String()))
+	assert.NoError(t, err, "Expected the calendar to be imported")
+	assert.Len(t, issues, 1, "Expected a single issue")
+	assert.Equal(t, "the period is in effect before DTSTART too", issues[0].Reason, "Expected the always period to start before the exported event")
+	assert.Equal(t, HalfOpen, imported.Boundary, "Expected the periods to exclude their end")
+	daily, weekly := c.Periods[0].(DailyPeriod), c.Periods[1].(WeeklyPeriod)
+	daily.ValidFrom = &TimestampEdge{Timestamp: time.Date(2025, 5, 7, 23, 0, 0, 0, time.UTC)}
+	weekly.ValidFrom = &TimestampEdge{Timestamp: time.Date(2025, 5, 9, 16, 0, 0, 0, time.UTC)}
+	assert.Equal(t, []Period{daily, weekly, c.Periods[2], c.Periods[3]}, imported.Periods, "Expected the periods to survive the round trip, starting from the exported events")
+}
+
+func TestICalendarImportRecurring(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err, "Expected the location to be loaded")
+	c, issues := icalendarImport(t, rome,
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:backup\r\nDTSTART;TZID=Europe/Rome:20250505T020000\r\nDURATION:PT1H30M\r\nRRULE:FREQ=DAILY\r\nBEGIN:VALARM\r\nACTION:DISPLAY\r\nTRIGGER:-PT15M\r\nEND:VALARM\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:patching\r\nDESCRIPTION:kernel\\, firmware\\nand more\r\nDTSTART:20250506T200000Z\r\nDTEND:20250506T230000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=TU,TH\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:3\r\nSUMMARY:weekend\r\nDTSTART;VALUE=DATE:20250510\r\nDTEND;VALUE=DATE:20250512\r\nRRULE:FREQ=WEEKLY\r\nEXDATE;VALUE=DATE:20250524\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:4\r\nSUMMARY:change freeze\r\nDTSTART;VALUE=DATE:20250101\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:5\r\nSUMMARY:cancelled\r\nSTATUS:CANCELLED\r\nDTSTART:20250506T200000Z\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\n",
+	)
+
+	since := func(t time.Time) Validity {
+		return Validity{ValidFrom: &TimestampEdge{Timestamp: t}}
+	}
+	assert.Equal(t, []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "backup"}, Validity: since(time.Date(2025, 5, 5, 2, 0, 0, 0, rome)), From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:30"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "patching", Description: "kernel, firmware\nand more"}, Validity: since(time.Date(2025, 5, 6, 22, 0, 0, 0, rome)), From: DayTimeEdge{Day: time.Tuesday, Hour: "22:00"}, To: DayTimeEdge{Day: time.Wednesday, Hour: "01:00"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "patching", Description: "kernel, firmware\nand more"}, Validity: since(time.Date(2025, 5, 6, 22, 0, 0, 0, rome)), From: DayTimeEdge{Day: time.Thursday, Hour: "22:00"}, To: DayTimeEdge{Day: time.Friday, Hour: "01:00"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "weekend"}, Validity: since(time.Date(2025, 5, 10, 0, 0, 0, 0, rome)), From: DayTimeEdge{Day: time.Saturday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "00:00"}},
+		AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "change freeze"}},
+	}, c.Periods, "Expected the recurring events to become recurring periods starting on DTSTART")
+	assert.False(t, c.Periods[0].Contains(time.Date(2025, 5, 4, 2, 30, 0, 0, rome)), "Expected no occurrence before DTSTART")
+	assert.True(t, c.Periods[0].Contains(time.Date(2025, 5, 5, 2, 30, 0, 0, rome)), "Expected the first occurrence on DTSTART")
+	assert.Equal(t, []ICalendarIssue{
+		{UID: "2", Summary: "patching", Reason: "the times follow Europe/Rome, whose daylight saving time differs from UTC", Imported: true},
+		{UID: "3", Summary: "weekend", Reason: "the 1 dates of EXDATE are ignored", Imported: true},
+		{UID: "4", Summary: "change freeze", Reason: "the period is in effect before DTSTART too", Imported: true},
+	}, issues, "Expected the approximations to be reported")
+	assert.Equal(t, `event "weekend" imported partially: the 1 dates of EXDATE are ignored`, issues[1].String(), "Expected the issue to be readable")
+}
+
+func TestICalendarImportBounded(t *testing.T) {
+	c, issues := icalendarImport(t, time.UTC,
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:migration\r\nDTSTART:20250601T100000Z\r\nDTEND:20250601T120000Z\r\nRDATE:20250615T100000Z\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:upgrade\r\nDTSTART:20250505T220000Z\r\nDURATION:PT2H\r\nRRULE:FREQ=DAILY;INTERVAL=2;COUNT=4\r\nEXDATE:20250507T220000Z\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:3\r\nSUMMARY:audit\r\nDTSTART:20250506T090000Z\r\nDURATION:PT1H\r\nRRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TU;UNTIL=20250527T090000Z\r\nEND:VEVENT\r\n",
+	)
+	assert.Empty(t, issues, "Expected no issues")
+
+	once := func(name string, day, hour, hours int) Period {
+		from := time.Date(2025, 5, day, hour, 0, 0, 0, time.UTC)
+		return OncePeriod{PeriodLabel: PeriodLabel{Name: name}, From: TimestampEdge{Timestamp: from}, To: TimestampEdge{Timestamp: from.Add(time.Duration(hours) * time.Hour)}}
+	}
+	assert.Equal(t, []Period{
+		once("migration", 32, 10, 2),
+		once("migration", 46, 10, 2),
+		once("upgrade", 5, 22, 2),
+		once("upgrade", 9, 22, 2),
+		once("upgrade", 11, 22, 2),
+		once("audit", 6, 9, 1),
+		once("audit", 19, 9, 1),
+		once("audit", 20, 9, 1),
+	}, c.Periods, "Expected the bounded events to become once periods")
+}
+
+func TestICalendarImportUnsupported(t *testing.T) {
+	c, issues := icalendarImport(t, time.UTC,
+		"BEGIN:VEVENT\r\nUID:1\r\nSUMMARY:monthly\r\nDTSTART:20250506T200000Z\r\nRRULE:FREQ=MONTHLY;BYDAY=1MO\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:2\r\nSUMMARY:fortnightly\r\nDTSTART:20250506T200000Z\r\nRRULE:FREQ=WEEKLY;INTERVAL=2\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:3\r\nSUMMARY:forever\r\nDTSTART:20250506T200000Z\r\nRRULE:FREQ=DAILY;UNTIL=21250506T200000Z\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:4\r\nSUMMARY:change freeze\r\nDTSTART;TZID=Mars/Olympus:20250506T200000\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:5\r\nSUMMARY:moved\r\nRECURRENCE-ID:20250507T200000Z\r\nDTSTART:20250507T210000Z\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:6\r\nSUMMARY:ok\r\nDTSTART:20250506T200000Z\r\nEND:VEVENT\r\n",
+		"BEGIN:VEVENT\r\nUID:7\r\nSUMMARY:reminder\r\nDTSTART:20250506T100000Z\r\nRRULE:FREQ=DAILY\r\nEND:VEVENT\r\n",
+	)
+	assert.Len(t, c.Periods, 1, "Expected only the supported event to be imported")
+	assert.False(t, c.Contains(time.Date(2025, 5, 7, 15, 0, 0, 0, time.UTC)), "Expected no period to contain the time between the reminders")
+	reasons := map[string]string{}
+	for _, issue := range issues {
+		assert.False(t, issue.Imported, "Expected %s not to be imported", issue.UID)
+		reasons[issue.UID] = issue.Reason
+	}
+	assert.Equal(t, map[string]string{
+		"1": "FREQ=MONTHLY is not supported",
+		"2": "repeating forever every 2 weeks is not supported",
+		"3": "more than 1000 occurrences",
+		"4": `unknown time zone "Mars/Olympus"`,
+		"5": "changing an occurrence of another event is not supported",
+		"7": "repeating forever without DTEND or DURATION is not supported",
+	}, reasons, "Expected the unsupported events to be reported")
+}
+
+func TestICalendarImportMalformed(t *testing.T) {
+	for name, ics := range map[string]string{
+		"not a calendar":  "BEGIN:VCARD\r\nEND:VCARD\r\n",
+		"unterminated":    "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nEND:VCALENDAR\r\n",
+		"missing colon":   "BEGIN:VCALENDAR\r\nSUMMARY\r\nEND:VCALENDAR\r\n",
+		"unclosed quote":  "BEGIN:VCALENDAR\r\nDTSTART;TZID=\"Europe/Rome:20250101\r\nEND:VCALENDAR\r\n",
+		"leading fold":    " BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n",
+		"missing the end": "BEGIN:VCALENDAR\r\n",
+	} {
+		_, _, err := ICalendar{}.Import(strings.NewReader(ics))
+		assert.Error(t, err, "Expected the %s file to be rejected", name)
+	}
+}
+
+func TestParseICalProperty(t *testing.T) {
+	p, err := parseICalProperty(`dtstart;tzid="America/New_York";x-note="a;b:c":20250101T090000`)
+	assert.NoError(t, err, "Expected the property to be parsed")
+	assert.Equal(t, icalProperty{
+		Name:   "DTSTART",
+		Params: map[string]string{"TZID": "America/New_York", "X-NOTE": "a;b:c"},
+		Value:  "20250101T090000",
+	}, p, "Expected the name, parameters and value")
+}
+
+func TestParseICalDuration(t *testing.T) {
+	for s, expected := range map[string][2]int64{
+		"P2W":       {14, 0},
+		"P1DT12H":   {1, int64(12 * time.Hour)},
+		"PT1H30M5S": {0, int64(time.Hour + 30*time.Minute + 5*time.Second)},
+		"+PT15M":    {0, int64(15 * time.Minute)},
+	} {
+		days, d, err := parseICalDuration(s)
+		assert.NoError(t, err, "Expected %s to be parsed", s)
+		assert.Equal(t, expected, [2]int64{int64(days), int64(d)}, "Expected the days and the duration of %s", s)
+	}
+	for _, s := range []string{"P", "PT", "-PT1H", "1H", "P1W2D"} {
+		_, _, err := parseICalDuration(s)
+		assert.Error(t, err, "Expected %s to be rejected", s)
+	}
+}
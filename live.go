@@ -0,0 +1,166 @@
+package casoncelli
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Live holds a Schedule that can be replaced while other goroutines are
+// reading it.
+//
+// Readers load the current schedule through an atomic pointer and are never
+// blocked; writers are serialized and publish a new compiled schedule along
+// with an increased version number. The zero value is an empty schedule,
+// ready to use.
+type Live struct {
+	// Clock is the source of time of the overrides, SystemClock if nil.
+	Clock Clock
+
+	mu      sync.Mutex
+	current atomic.Pointer[liveSnapshot]
+	// first is closed when the first schedule is published
+	first chan struct{}
+}
+
+type liveSnapshot struct {
+	schedule *Schedule
+	version  uint64
+	replaced chan struct{}
+}
+
+// NewLive compiles c and returns a Live holding it, at version 1.
+func NewLive(c Casoncelli) (*Live, error) {
+	l := &Live{}
+	if _, err := l.Replace(c); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Load returns the current schedule along with its version.
+func (l *Live) Load() (*Schedule, uint64) {
+	snap := l.current.Load()
+	if snap == nil {
+		return emptySchedule, 0
+	}
+	return snap.schedule, snap.version
+}
+
+// Schedule returns the current schedule.
+func (l *Live) Schedule() *Schedule {
+	s, _ := l.Load()
+	return s
+}
+
+// Version returns the version of the current schedule. It starts at 0 for
+// an empty Live and increases every time the schedule changes.
+func (l *Live) Version() uint64 {
+	_, v := l.Load()
+	return v
+}
+
+// Changed returns a channel that is closed when the current schedule is
+// replaced. Call Changed before Load to not miss a replacement.
+func (l *Live) Changed() <-chan struct{} {
+	if snap := l.current.Load(); snap != nil {
+		return snap.replaced
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if snap := l.current.Load(); snap != nil {
+		return snap.replaced
+	}
+	if l.first == nil {
+		l.first = make(chan struct{})
+	}
+	return l.first
+}
+
+// Contains reports whether t is included in the current schedule.
+func (l *Live) Contains(t time.Time) bool {
+	return l.Schedule().Contains(t)
+}
+
+// ContainsNow reports whether the current moment is included in the current schedule.
+func (l *Live) ContainsNow() bool {
+	return l.Schedule().ContainsNow()
+}
+
+// Replace compiles c and makes it the current schedule, returning the new
+// version. If c is not valid, the current schedule is kept.
+func (l *Live) Replace(c Casoncelli) (uint64, error) {
+	return l.update(func(Casoncelli, uint64) (Casoncelli, error) {
+		return c, nil
+	})
+}
+
+// ErrVersionConflict is returned by ReplaceIf when the schedule is no longer
+// at the expected version.
+var ErrVersionConflict = errors.New("the schedule changed since the expected version")
+
+// ReplaceIf is like Replace, but replaces the schedule only while it is at
+// version, returning ErrVersionConflict otherwise.
+func (l *Live) ReplaceIf(version uint64, c Casoncelli) (uint64, error) {
+	return l.update(func(_ Casoncelli, current uint64) (Casoncelli, error) {
+		if current != version {
+			return c, fmt.Errorf("%w: expected version %d, found %d", ErrVersionConflict, version, current)
+		}
+		return c, nil
+	})
+}
+
+// Add appends the period p to the current schedule, returning the new version.
+func (l *Live) Add(p Period) (uint64, error) {
+	return l.update(func(c Casoncelli, _ uint64) (Casoncelli, error) {
+		c.Periods = append(c.Periods, p)
+		return c, nil
+	})
+}
+
+// Remove deletes the periods named name from the current schedule,
+// returning the new version.
+func (l *Live) Remove(name string) (uint64, error) {
+	return l.update(func(c Casoncelli, _ uint64) (Casoncelli, error) {
+		n := len(c.Periods)
+		c.Periods = slices.DeleteFunc(c.Periods, func(p Period) bool {
+			return labelOf(p).Name == name
+		})
+		if len(c.Periods) == n {
+			return c, fmt.Errorf("no period named %s", name)
+		}
+		return c, nil
+	})
+}
+
+// update applies change to a copy of the current Casoncelli, at its version,
+// and publishes the result.
+func (l *Live) update(change func(Casoncelli, uint64) (Casoncelli, error)) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current, version := l.Load()
+	c, err := change(current.Casoncelli(), version)
+	if err != nil {
+		return version, err
+	}
+	schedule, err := c.Compile()
+	if err != nil {
+		return version, err
+	}
+
+	version++
+	previous := l.current.Swap(&liveSnapshot{schedule: schedule, version: version, replaced: make(chan struct{})})
+	if previous != nil {
+		close(previous.replaced)
+	} else if l.first != nil {
+		close(l.first)
+	}
+	return version, nil
+}
+
+var emptySchedule, _ = (&Casoncelli{}).Compile()
@@ -0,0 +1,117 @@
+package casoncelli
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLive returns a Live of the periods, failing the test if they do not
+// compile.
+func newTestLive(t *testing.T, periods ...Period) *Live {
+	t.Helper()
+	live, err := NewLive(Casoncelli{Periods: periods})
+	assert.NoError(t, err, "Expected the schedule to compile")
+	return live
+}
+
+func TestLiveZeroValue(t *testing.T) {
+	var l Live
+	assert.False(t, l.ContainsNow(), "Expected an empty live schedule to not contain now")
+	assert.Equal(t, uint64(0), l.Version(), "Expected an empty live schedule to be at version 0")
+	assert.NotNil(t, l.Schedule(), "Expected an empty live schedule to return a schedule")
+}
+
+func TestLiveReplace(t *testing.T) {
+	l, err := NewLive(Casoncelli{Periods: []Period{NeverPeriod{}}})
+	assert.NoError(t, err, "Expected no error creating the live schedule")
+	assert.Equal(t, uint64(1), l.Version(), "Expected a new live schedule to be at version 1")
+	assert.False(t, l.ContainsNow(), "Expected the never period to not contain now")
+
+	version, err := l.Replace(Casoncelli{Periods: []Period{AlwaysPeriod{}}})
+	assert.NoError(t, err, "Expected no error replacing the schedule")
+	assert.Equal(t, uint64(2), version, "Expected the version to increase")
+	assert.True(t, l.ContainsNow(), "Expected the always period to contain now")
+
+	invalid := Casoncelli{Periods: []Period{DailyPeriod{From: TimeEdge{Hour: "xx"}, To: TimeEdge{Hour: "10:00"}}}}
+	version, err = l.Replace(invalid)
+	assert.Error(t, err, "Expected an error replacing with an invalid schedule")
+	assert.Equal(t, uint64(2), version, "Expected the version to not change")
+	assert.True(t, l.ContainsNow(), "Expected the last good schedule to be kept")
+
+	_, err = NewLive(invalid)
+	assert.Error(t, err, "Expected an error creating a live schedule from an invalid one")
+}
+
+func TestLiveAddRemove(t *testing.T) {
+	var l Live
+	version, err := l.Add(AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "emergency"}})
+	assert.NoError(t, err, "Expected no error adding a period")
+	assert.Equal(t, uint64(1), version, "Expected the version to increase")
+	assert.True(t, l.ContainsNow(), "Expected the added period to be evaluated")
+
+	_, err = l.Add(NeverPeriod{PeriodLabel: PeriodLabel{Name: "placeholder"}})
+	assert.NoError(t, err, "Expected no error adding a second period")
+	assert.Len(t, l.Schedule().Casoncelli().Periods, 2, "Expected the schedule to hold both periods")
+
+	version, err = l.Remove("emergency")
+	assert.NoError(t, err, "Expected no error removing a period")
+	assert.Equal(t, uint64(3), version, "Expected the version to increase")
+	assert.False(t, l.ContainsNow(), "Expected the removed period to not be evaluated")
+	assert.Len(t, l.Schedule().Casoncelli().Periods, 1, "Expected a single period to be left")
+
+	version, err = l.Remove("emergency")
+	assert.Error(t, err, "Expected an error removing a missing period")
+	assert.Equal(t, uint64(3), version, "Expected the version to not change")
+}
+
+func TestLiveReplaceIf(t *testing.T) {
+	l, err := NewLive(Casoncelli{Periods: []Period{NeverPeriod{}}})
+	assert.NoError(t, err, "Expected no error creating the live schedule")
+
+	version, err := l.ReplaceIf(1, Casoncelli{Periods: []Period{AlwaysPeriod{}}})
+	assert.NoError(t, err, "Expected the schedule at the expected version to be replaced")
+	assert.Equal(t, uint64(2), version, "Expected the version to increase")
+
+	version, err = l.ReplaceIf(1, Casoncelli{})
+	assert.ErrorIs(t, err, ErrVersionConflict, "Expected a stale version to conflict")
+	assert.Equal(t, uint64(2), version, "Expected the current version")
+	assert.True(t, l.ContainsNow(), "Expected the schedule to be kept")
+}
+
+func TestLiveConcurrentAccess(t *testing.T) {
+	var l Live
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.ContainsNow()
+				l.Load()
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				l.Add(DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}})
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, uint64(100), l.Version(), "Expected every change to increase the version")
+	assert.Len(t, l.Schedule().Casoncelli().Periods, 100, "Expected no change to be lost")
+}
+
+func TestLiveScheduleCopy(t *testing.T) {
+	l, _ := NewLive(Casoncelli{Periods: []Period{AlwaysPeriod{}}})
+	c := l.Schedule().Casoncelli()
+	c.Periods[0] = NeverPeriod{}
+	assert.True(t, l.Contains(time.Now()), "Expected changes to the returned casoncelli to not affect the schedule")
+}
@@ -0,0 +1,189 @@
+package casoncelli
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"html/template"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Template renders the maintenance page. Both html/template and
+// text/template satisfy it.
+type Template interface {
+	Execute(w io.Writer, data any) error
+}
+
+// MaintenanceInfo is the data available to the maintenance Template.
+type MaintenanceInfo struct {
+	// Name and Description are the label of the first active period.
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Periods are the labels of all the active periods.
+	Periods []PeriodLabel `json:"periods"`
+	// End is the expected end of the maintenance, nil if unknown.
+	End *time.Time `json:"end,omitempty"`
+	// RetryAfter is the number of seconds until End, zero if unknown.
+	RetryAfter int `json:"retry_after,omitempty"`
+	// Override is the override forcing the maintenance, if any. Its Reason is
+	// the Description when no period is active.
+	Override *Override `json:"override,omitempty"`
+}
+
+// DefaultMaintenanceTemplate is the HTML page served by a Maintenance
+// without a Template.
+var DefaultMaintenanceTemplate = template.Must(template.New("maintenance").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Service under maintenance</title></head>
+<body>
+<h1>Service under maintenance</h1>
+{{with .Name}}<p><strong>{{.}}</strong></p>{{end}}
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{with .End}}<p>The service is expected to be back at {{.Format "2006-01-02 15:04:05 MST"}}.</p>{{end}}
+</body>
+</html>
+`))
+
+// Maintenance is an HTTP middleware answering 503 Service Unavailable while
+// its schedule is active.
+//
+// The response is rendered with Template, or as JSON for the clients
+// accepting only application/json. The Retry-After header is set to the end
+// of the current merged occurrence, when it is known. Requests matching one
+// of the bypass rules are always forwarded.
+type Maintenance struct {
+	// Live is the maintenance schedule.
+	Live *Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+
+	// Template renders the maintenance page, DefaultMaintenanceTemplate if nil.
+	Template Template
+	// ContentType is the content type of the rendered Template,
+	// "text/html; charset=utf-8" if empty.
+	ContentType string
+
+	// BypassHeader is the name of the header carrying a bypass token.
+	BypassHeader string
+	// BypassTokens are the tokens accepted in BypassHeader.
+	BypassTokens []string
+	// BypassNetworks are the client networks always allowed through.
+	BypassNetworks []netip.Prefix
+	// BypassPaths are the path.Match patterns of the URL paths always
+	// allowed through, like "/healthz".
+	BypassPaths []string
+}
+
+// Handler returns a handler serving the maintenance response while the
+// schedule is active, and forwarding the requests to next otherwise.
+func (m *Maintenance) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := clockOrSystem(m.Clock).Now()
+		schedule := m.Live.Schedule()
+		if !schedule.Contains(now) || m.bypass(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		m.serve(w, r, maintenanceInfo(schedule, now))
+	})
+}
+
+func maintenanceInfo(s *Schedule, now time.Time) MaintenanceInfo {
+	info := MaintenanceInfo{Periods: []PeriodLabel{}}
+	for _, occ := range s.Active(now) {
+		info.Periods = append(info.Periods, occ.Label)
+	}
+	if len(info.Periods) > 0 {
+		info.Name, info.Description = info.Periods[0].Name, info.Periods[0].Description
+	}
+	if o, ok := s.OverrideAt(now); ok {
+		info.Override = &o
+		if len(info.Periods) == 0 {
+			info.Description = o.Reason
+		}
+	}
+	if end, ok := s.CurrentEnd(now); ok {
+		info.End = &end
+		info.RetryAfter = int(math.Ceil(end.Sub(now).Seconds()))
+	}
+	return info
+}
+
+func (m *Maintenance) serve(w http.ResponseWriter, r *http.Request, info MaintenanceInfo) {
+	if info.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(info.RetryAfter))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	if m.Template == nil && prefersJSON(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(info)
+		return
+	}
+
+	tmpl, contentType := m.Template, m.ContentType
+	if tmpl == nil {
+		tmpl = DefaultMaintenanceTemplate
+	}
+	if contentType == "" {
+		contentType = "text/html; charset=utf-8"
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, info); err != nil {
+		http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(body.Bytes())
+}
+
+// bypass reports whether the request matches one of the bypass rules.
+func (m *Maintenance) bypass(r *http.Request) bool {
+	for _, pattern := range m.BypassPaths {
+		if ok, _ := path.Match(pattern, r.URL.Path); ok {
+			return true
+		}
+	}
+
+	if m.BypassHeader != "" {
+		if token := r.Header.Get(m.BypassHeader); token != "" {
+			for _, accepted := range m.BypassTokens {
+				if subtle.ConstantTimeCompare([]byte(token), []byte(accepted)) == 1 {
+					return true
+				}
+			}
+		}
+	}
+
+	if len(m.BypassNetworks) > 0 {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		if addr, err := netip.ParseAddr(host); err == nil {
+			addr = addr.Unmap()
+			for _, network := range m.BypassNetworks {
+				if network.Contains(addr) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// prefersJSON reports whether the client accepts JSON but not HTML.
+func prefersJSON(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "application/json") && !strings.Contains(accept, "text/html")
+}
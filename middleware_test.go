@@ -0,0 +1,168 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+})
+
+func serveMaintenance(m *Maintenance, r *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	m.Handler(okHandler).ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMaintenanceInactive(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)),
+	}
+
+	rec := serveMaintenance(m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the request to be forwarded outside the maintenance")
+	assert.Equal(t, "ok", rec.Body.String(), "Expected the wrapped handler to answer")
+}
+
+func TestMaintenanceHTML(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+
+	rec := serveMaintenance(m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the maintenance response")
+	assert.Equal(t, "1800", rec.Header().Get("Retry-After"), "Expected Retry-After to point to the end of the maintenance")
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"), "Expected an HTML page")
+	assert.Contains(t, rec.Body.String(), "scheduled maintenance", "Expected the page to show the period name")
+	assert.Contains(t, rec.Body.String(), "update indexes", "Expected the page to show the period description")
+	assert.Contains(t, rec.Body.String(), "2025-05-05 03:00:00", "Expected the page to show the expected end")
+}
+
+func TestMaintenanceJSON(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api", nil)
+	r.Header.Set("Accept", "application/json")
+	rec := serveMaintenance(m, r)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the maintenance response")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "Expected a JSON response")
+
+	var info MaintenanceInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info), "Expected a valid JSON body")
+	assert.Equal(t, "scheduled maintenance", info.Name, "Expected the period name")
+	assert.Equal(t, 1800, info.RetryAfter, "Expected the seconds until the end")
+	assert.True(t, time.Date(2025, 5, 5, 3, 0, 0, 0, time.UTC).Equal(*info.End), "Expected the expected end")
+	assert.Len(t, info.Periods, 1, "Expected the active periods")
+}
+
+func TestMaintenanceCustomTemplate(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+	m.Template = template.Must(template.New("text").Parse("down for {{.Name}} for {{.RetryAfter}}s"))
+	m.ContentType = "text/plain"
+
+	rec := serveMaintenance(m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"), "Expected the custom content type")
+	assert.Equal(t, "down for scheduled maintenance for 1800s", rec.Body.String(), "Expected the custom template")
+}
+
+func TestMaintenanceUnknownEnd(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "maintenance mode"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+
+	rec := serveMaintenance(m, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the maintenance response")
+	assert.Empty(t, rec.Header().Get("Retry-After"), "Expected no Retry-After without an end")
+	assert.NotContains(t, rec.Body.String(), "expected to be back", "Expected no end in the page")
+}
+
+func TestMaintenanceBypass(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+	m.BypassPaths = []string{"/healthz", "/debug/*"}
+	m.BypassHeader = "X-Maintenance-Bypass"
+	m.BypassTokens = []string{"secret"}
+	m.BypassNetworks = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("2001:db8::/32")}
+
+	bypassed := map[string]*http.Request{}
+	bypassed["path"] = httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	bypassed["pattern"] = httptest.NewRequest(http.MethodGet, "/debug/vars", nil)
+	bypassed["token"] = httptest.NewRequest(http.MethodGet, "/", nil)
+	bypassed["token"].Header.Set("X-Maintenance-Bypass", "secret")
+	bypassed["ipv4"] = httptest.NewRequest(http.MethodGet, "/", nil)
+	bypassed["ipv4"].RemoteAddr = "10.1.2.3:4567"
+	bypassed["ipv6"] = httptest.NewRequest(http.MethodGet, "/", nil)
+	bypassed["ipv6"].RemoteAddr = "[2001:db8::1]:4567"
+	for name, r := range bypassed {
+		rec := serveMaintenance(m, r)
+		assert.Equal(t, http.StatusOK, rec.Code, "Expected the %s rule to bypass the maintenance", name)
+	}
+
+	blocked := map[string]*http.Request{}
+	blocked["path"] = httptest.NewRequest(http.MethodGet, "/healthz/deep", nil)
+	blocked["token"] = httptest.NewRequest(http.MethodGet, "/", nil)
+	blocked["token"].Header.Set("X-Maintenance-Bypass", "guess")
+	blocked["ip"] = httptest.NewRequest(http.MethodGet, "/", nil)
+	blocked["ip"].RemoteAddr = "192.168.1.1:4567"
+	for name, r := range blocked {
+		rec := serveMaintenance(m, r)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the %s request to not bypass the maintenance", name)
+	}
+}
+
+func TestMaintenanceLiveReplace(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+	handler := m.Handler(okHandler)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the maintenance response")
+
+	m.Live.Replace(Casoncelli{})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the replaced schedule to be used")
+	assert.True(t, strings.HasPrefix(rec.Body.String(), "ok"), "Expected the wrapped handler to answer")
+}
+
+func TestMaintenanceOverride(t *testing.T) {
+	m := &Maintenance{
+		Live:  newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "scheduled maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}),
+		Clock: newFakeClock(time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)),
+	}
+	_, err := m.Live.Override(Override{Active: true, From: time.Date(2025, 5, 5, 11, 45, 0, 0, time.UTC), Until: time.Date(2025, 5, 5, 12, 30, 0, 0, time.UTC), Reason: "database failover"})
+	assert.NoError(t, err, "Expected the override to be added")
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/json")
+	rec := serveMaintenance(m, r)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "Expected the maintenance to be forced")
+	assert.Equal(t, "1800", rec.Header().Get("Retry-After"), "Expected Retry-After to point to the end of the override")
+	var info MaintenanceInfo
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &info), "Expected a valid JSON body")
+	assert.Equal(t, "database failover", info.Description, "Expected the reason of the override")
+	assert.True(t, info.Override.Active, "Expected the override")
+}
@@ -1,6 +1,7 @@
 package casoncelli
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 )
@@ -9,6 +10,13 @@ type NeverPeriod struct {
 	PeriodLabel
 }
 
+func (n NeverPeriod) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		PeriodLabel
+	}{"never", n.PeriodLabel})
+}
+
 func (n NeverPeriod) Contains(time.Time) bool {
 	return false
 }
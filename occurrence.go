@@ -0,0 +1,315 @@
+package casoncelli
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// recurringLookaround is how far a search for the occurrences of the
+// recurring periods has to look to find at least one of them.
+const recurringLookaround = 8 * 24 * time.Hour
+
+// Occurrence is a single occurrence of a period, from its start to its end.
+//
+// Periods without edges, like AlwaysPeriod, have a single unbounded
+// occurrence whose Start and End are zero.
+type Occurrence struct {
+	Start    time.Time
+	End      time.Time
+	Boundary Boundary
+	// Index is the position of the period in Casoncelli.Periods.
+	Index int
+	Label PeriodLabel
+}
+
+// Unbounded reports whether the occurrence has no edges.
+func (o Occurrence) Unbounded() bool {
+	return o.Start.IsZero() && o.End.IsZero()
+}
+
+// Contains reports whether t is included in the occurrence, honoring its boundary.
+func (o Occurrence) Contains(t time.Time) bool {
+	if o.Unbounded() {
+		return true
+	}
+	afterStart := o.Start.Before(t) || (o.Boundary.includesStart() && o.Start.Equal(t))
+	beforeEnd := o.End.After(t) || (o.Boundary.includesEnd() && o.End.Equal(t))
+	return afterStart && beforeEnd
+}
+
+// occurrer is implemented by the periods able to list their occurrences.
+type occurrer interface {
+	// occurrences returns the occurrences of the period overlapping
+	// [from, to], in chronological order.
+	occurrences(from, to time.Time) []Occurrence
+}
+
+// Occurrences returns the occurrences of the periods overlapping [from, to],
+// sorted by start. Periods that are not defined by this package are skipped,
+// and the overrides are not applied: see Merged.
+//
+// The excluding periods have no occurrences of their own: they cut the
+// occurrences of the including periods of the same or a lower priority, and
+// an unbounded occurrence cut by them is bounded to [from, to].
+func (s *Schedule) Occurrences(from, to time.Time) []Occurrence {
+	var result []Occurrence
+	for i, period := range s.periods {
+		o, ok := period.(occurrer)
+		if !ok || labelOf(period).Effect == Exclude {
+			continue
+		}
+		label := labelOf(period)
+		for _, occ := range o.occurrences(from, to) {
+			occ.Index, occ.Label = i, label
+			result = append(result, occ)
+		}
+	}
+	if s.layered {
+		result = s.punch(result, from, to)
+	}
+	slices.SortFunc(result, compareOccurrences)
+	return result
+}
+
+// Active returns the occurrences containing t, sorted by start.
+func (s *Schedule) Active(t time.Time) []Occurrence {
+	var result []Occurrence
+	for _, occ := range s.Occurrences(t, t) {
+		if occ.Contains(t) {
+			result = append(result, occ)
+		}
+	}
+	return result
+}
+
+// Merged returns the union of the occurrences overlapping [from, to], as
+// sorted and disjoint occurrences. Merged occurrences have no period, so
+// their Index is -1 and their Label is empty.
+//
+// Unlike Occurrences, Merged honors the overrides: the forced active ones
+// are added, and the forced inactive ones cut out. An unbounded occurrence
+// cut by an override is bounded to [from, to].
+func (s *Schedule) Merged(from, to time.Time) []Occurrence {
+	return s.overridden(merge(s.Occurrences(from, to)), from, to)
+}
+
+// maxMergeHorizon is how far CurrentEnd looks for the end of chained
+// occurrences, and Next for the following ones.
+const maxMergeHorizon = 366 * 24 * time.Hour
+
+// Next returns the first n occurrences containing t or starting after it,
+// sorted by start, looking up to a year ahead.
+func (s *Schedule) Next(t time.Time, n int) []Occurrence {
+	return next(t, n, s.Occurrences)
+}
+
+// NextMerged is like Next, but returns merged occurrences.
+func (s *Schedule) NextMerged(t time.Time, n int) []Occurrence {
+	return next(t, n, s.Merged)
+}
+
+func next(t time.Time, n int, list func(from, to time.Time) []Occurrence) []Occurrence {
+	var result []Occurrence
+	for horizon := recurringLookaround; ; horizon *= 4 {
+		result = result[:0]
+		for _, occ := range list(t, t.Add(horizon)) {
+			if occ.Contains(t) || occ.Start.After(t) {
+				result = append(result, occ)
+			}
+		}
+		if len(result) >= n || horizon >= maxMergeHorizon {
+			break
+		}
+	}
+	return result[:min(n, len(result))]
+}
+
+// CurrentEnd returns the end of the merged occurrence containing t, that is
+// the first time after t not contained in any period. It reports false if
+// t is not contained, or if no end can be found, like for an AlwaysPeriod.
+func (s *Schedule) CurrentEnd(t time.Time) (time.Time, bool) {
+	if !s.Contains(t) {
+		return time.Time{}, false
+	}
+	for horizon := recurringLookaround; horizon <= maxMergeHorizon; horizon *= 4 {
+		limit := t.Add(horizon)
+		for _, m := range s.Merged(t.Add(-recurringLookaround), limit) {
+			if !m.Contains(t) {
+				continue
+			}
+			if m.Unbounded() {
+				return time.Time{}, false
+			}
+			if m.End.Before(limit) {
+				return m.End, true
+			}
+			break
+		}
+	}
+	return time.Time{}, false
+}
+
+// merge joins the overlapping or adjacent occurrences, sorted by start.
+func merge(occurrences []Occurrence) []Occurrence {
+	var result []Occurrence
+	var current Occurrence
+	var startIn, endIn, open bool
+	flush := func() {
+		if open {
+			current.Boundary = boundaryOf(startIn, endIn)
+			result = append(result, current)
+		}
+	}
+	for _, occ := range occurrences {
+		if occ.Unbounded() {
+			return []Occurrence{{Index: -1}}
+		}
+		joins := open && (occ.Start.Before(current.End) ||
+			(occ.Start.Equal(current.End) && (endIn || occ.Boundary.includesStart())))
+		if !joins {
+			flush()
+			current = Occurrence{Start: occ.Start, End: occ.End, Index: -1}
+			startIn, endIn, open = occ.Boundary.includesStart(), occ.Boundary.includesEnd(), true
+			continue
+		}
+		if occ.Start.Equal(current.Start) {
+			startIn = startIn || occ.Boundary.includesStart()
+		}
+		switch {
+		case occ.End.After(current.End):
+			current.End, endIn = occ.End, occ.Boundary.includesEnd()
+		case occ.End.Equal(current.End):
+			endIn = endIn || occ.Boundary.includesEnd()
+		}
+	}
+	flush()
+	return result
+}
+
+// boundaryOf returns the boundary including the given edges.
+func boundaryOf(startIn, endIn bool) Boundary {
+	switch {
+	case startIn && endIn:
+		return Closed
+	case startIn:
+		return HalfOpen
+	case endIn:
+		return OpenClosed
+	default:
+		return Open
+	}
+}
+
+// nextEdge returns the first start or end of an occurrence after t.
+func (s *Schedule) nextEdge(t time.Time) (time.Time, bool) {
+	var next time.Time
+	consider := func(edge time.Time) {
+		if edge.After(t) && (next.IsZero() || edge.Before(next)) {
+			next = edge
+		}
+	}
+	for _, occ := range s.Occurrences(t.Add(-recurringLookaround), t.Add(recurringLookaround)) {
+		consider(occ.Start)
+		consider(occ.End)
+	}
+	// once periods may be farther than the recurring ones
+	for _, period := range s.periods {
+		if p, ok := period.(OncePeriod); ok {
+			consider(p.From.Timestamp)
+			consider(p.To.Timestamp)
+		}
+	}
+	for _, o := range s.source.Overrides {
+		consider(o.From)
+		consider(o.Until)
+	}
+	return next, !next.IsZero()
+}
+
+func (p DailyPeriod) occurrences(from, to time.Time) []Occurrence {
+	span := 0
+	if p.wraps() {
+		span = 1
+	}
+	year, month, day := from.AddDate(0, 0, -1).Date()
+	return p.clip(recurringOccurrences(from, to, time.Date(year, month, day, 0, 0, 0, 0, from.Location()), 1, span, p.From.Hour, p.To.Hour, p.Boundary))
+}
+
+func (p WeeklyPeriod) occurrences(from, to time.Time) []Occurrence {
+	span := (int(p.To.Day) - int(p.From.Day) + 7) % 7
+	if span == 0 && (p.To.Hour < p.From.Hour || (p.To.Hour == p.From.Hour && !p.Boundary.isClosed())) {
+		span = 7
+	}
+	base := from.AddDate(0, 0, -7)
+	year, month, day := base.Date()
+	day += (int(p.From.Day) - int(base.Weekday()) + 7) % 7
+	return p.clip(recurringOccurrences(from, to, time.Date(year, month, day, 0, 0, 0, 0, from.Location()), 7, span, p.From.Hour, p.To.Hour, p.Boundary))
+}
+
+// recurringOccurrences lists the occurrences overlapping [from, to] of a
+// period starting at the hour start every step days from the first day,
+// and ending span days later at the hour end.
+func recurringOccurrences(from, to, first time.Time, step, span int, start, end string, b Boundary) []Occurrence {
+	sh, sm := hourMinute(start)
+	eh, em := hourMinute(end)
+	year, month, day := first.Date()
+
+	var result []Occurrence
+	for ; ; day += step {
+		occ := Occurrence{
+			Start:    time.Date(year, month, day, sh, sm, 0, 0, first.Location()),
+			End:      time.Date(year, month, day+span, eh, em, 0, 0, first.Location()),
+			Boundary: b,
+		}
+		if occ.Start.After(to) {
+			return result
+		}
+		if !occ.End.Before(from) {
+			result = append(result, occ)
+		}
+	}
+}
+
+func (p OncePeriod) occurrences(from, to time.Time) []Occurrence {
+	if p.From.After(to) || p.To.Before(from) {
+		return nil
+	}
+	return []Occurrence{{Start: p.From.Timestamp, End: p.To.Timestamp, Boundary: p.Boundary}}
+}
+
+func (a AlwaysPeriod) occurrences(from, to time.Time) []Occurrence {
+	return []Occurrence{{}}
+}
+
+func (n NeverPeriod) occurrences(from, to time.Time) []Occurrence {
+	return nil
+}
+
+// hourMinute splits a valid "HH:MM" hour in its components.
+func hourMinute(hour string) (int, int) {
+	offset, _ := parseHour(hour)
+	return int(offset / int64(time.Hour)), int(offset % int64(time.Hour) / int64(time.Minute))
+}
+
+func minTime(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// compareOccurrences orders occurrences by start, then by period.
+func compareOccurrences(a, b Occurrence) int {
+	if c := a.Start.Compare(b.Start); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Index, b.Index)
+}
@@ -0,0 +1,258 @@
+package casoncelli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleOccurrences(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC) // monday
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "night"}, From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}},
+		WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "weekend"}, From: DayTimeEdge{Day: time.Saturday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "00:00"}},
+		OncePeriod{PeriodLabel: PeriodLabel{Name: "outage"}, From: TimestampEdge{Timestamp: from.Add(36 * time.Hour)}, To: TimestampEdge{Timestamp: from.Add(38 * time.Hour)}},
+		NeverPeriod{PeriodLabel: PeriodLabel{Name: "never"}},
+	}}
+	schedule, _ := c.Compile()
+
+	occurrences := schedule.Occurrences(from, from.AddDate(0, 0, 7))
+	var names []string
+	for _, occ := range occurrences {
+		names = append(names, occ.Label.Name)
+	}
+	assert.Equal(t, []string{"weekend", "night", "night", "outage", "night", "night", "night", "night", "weekend", "night", "night"}, names, "Expected the occurrences to be sorted by start")
+
+	previous := occurrences[0]
+	assert.Equal(t, from, previous.End, "Expected the weekend ending at the start of the range to be included")
+
+	first := occurrences[1]
+	assert.Equal(t, from.Add(-2*time.Hour), first.Start, "Expected the occurrence started the day before to be included")
+	assert.Equal(t, from.Add(6*time.Hour), first.End, "Expected the occurrence to end in the morning")
+	assert.Equal(t, 0, first.Index, "Expected the occurrence to refer to its period")
+
+	weekend := occurrences[8]
+	assert.Equal(t, from.AddDate(0, 0, 5), weekend.Start, "Expected the weekend to start on saturday")
+	assert.Equal(t, from.AddDate(0, 0, 7), weekend.End, "Expected the weekend to end on monday")
+	assert.Equal(t, 1, weekend.Index, "Expected the occurrence to refer to its period")
+}
+
+func TestScheduleOccurrencesIDs(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{ID: "night", Name: "maintenance"}, From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}},
+		AlwaysPeriod{PeriodLabel: PeriodLabel{ID: "outage"}},
+	}}
+	schedule, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	var ids []string
+	for _, occ := range schedule.Occurrences(from, from.Add(12*time.Hour)) {
+		ids = append(ids, occ.Label.ID)
+	}
+	assert.Equal(t, []string{"outage", "night"}, ids, "Expected the occurrences to carry the ids of their periods")
+	assert.Equal(t, "night", schedule.Next(from.Add(12*time.Hour), 2)[1].Label.ID, "Expected the next occurrences to carry the ids")
+}
+
+func TestScheduleOccurrencesMatchContains(t *testing.T) {
+	start := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		NeverPeriod{},
+		OncePeriod{From: TimestampEdge{Timestamp: start}, To: TimestampEdge{Timestamp: start.Add(2 * time.Hour)}},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(time.Hour)}, To: TimestampEdge{Timestamp: start.Add(3 * time.Hour)}, Boundary: Open},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(-time.Hour)}, To: TimestampEdge{Timestamp: start.Add(-time.Hour)}},
+	}}
+	for _, b := range []Boundary{"", Closed, HalfOpen, Open} {
+		c.Periods = append(c.Periods,
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "12:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Tuesday, Hour: "07:35"}, To: DayTimeEdge{Day: time.Thursday, Hour: "22:22"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Friday, Hour: "18:00"}, To: DayTimeEdge{Day: time.Friday, Hour: "10:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, Boundary: b},
+		)
+	}
+	schedule, _ := c.Compile()
+	instants := scheduleInstants()
+	from, to := instants[0], instants[0]
+	for _, ts := range instants {
+		from, to = minTime(from, ts), maxTime(to, ts)
+	}
+	occurrences := schedule.Occurrences(from, to)
+
+	for _, ts := range instants {
+		for i, period := range c.Periods {
+			contained := false
+			for _, occ := range occurrences {
+				if occ.Index == i && occ.Contains(ts) {
+					contained = true
+				}
+			}
+			assert.Equal(t, period.Contains(ts), contained, "Expected the occurrences of period %d to match Contains at %s", i, ts.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+func TestScheduleOccurrencesAlways(t *testing.T) {
+	c := Casoncelli{Periods: []Period{AlwaysPeriod{}}}
+	schedule, _ := c.Compile()
+	occurrences := schedule.Occurrences(time.Now(), time.Now().Add(time.Hour))
+	assert.Len(t, occurrences, 1, "Expected a single occurrence for the always period")
+	assert.True(t, occurrences[0].Unbounded(), "Expected the always occurrence to be unbounded")
+	assert.True(t, occurrences[0].Contains(time.Now()), "Expected the unbounded occurrence to contain any time")
+}
+
+func TestScheduleNextEdge(t *testing.T) {
+	now := time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		OncePeriod{From: TimestampEdge{Timestamp: now.AddDate(1, 0, 0)}, To: TimestampEdge{Timestamp: now.AddDate(1, 0, 1)}},
+	}}
+	schedule, _ := c.Compile()
+	next, ok := schedule.nextEdge(now)
+	assert.True(t, ok, "Expected a far once period to be found")
+	assert.Equal(t, now.AddDate(1, 0, 0), next, "Expected the start of the once period")
+
+	c.Periods = append(c.Periods, DailyPeriod{From: TimeEdge{Hour: "13:00"}, To: TimeEdge{Hour: "14:00"}})
+	schedule, _ = c.Compile()
+	next, _ = schedule.nextEdge(now)
+	assert.Equal(t, now.Add(time.Hour), next, "Expected the start of the daily period")
+
+	c = Casoncelli{Periods: []Period{AlwaysPeriod{}}}
+	schedule, _ = c.Compile()
+	_, ok = schedule.nextEdge(now)
+	assert.False(t, ok, "Expected no edge for an always period")
+}
+
+func TestScheduleMerged(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}, Boundary: HalfOpen},
+		DailyPeriod{From: TimeEdge{Hour: "17:00"}, To: TimeEdge{Hour: "18:00"}, Boundary: HalfOpen},
+		DailyPeriod{From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "13:00"}},
+		DailyPeriod{From: TimeEdge{Hour: "20:00"}, To: TimeEdge{Hour: "21:00"}, Boundary: Open},
+		DailyPeriod{From: TimeEdge{Hour: "21:00"}, To: TimeEdge{Hour: "22:00"}, Boundary: Open},
+	}}
+	schedule, _ := c.Compile()
+
+	merged := schedule.Merged(from, from.Add(23*time.Hour))
+	assert.Len(t, merged, 3, "Expected the adjacent and overlapping occurrences to be merged")
+	assert.Equal(t, from.Add(9*time.Hour), merged[0].Start, "Expected the first merged occurrence to start at 09:00")
+	assert.Equal(t, from.Add(18*time.Hour), merged[0].End, "Expected the first merged occurrence to end at 18:00")
+	assert.Equal(t, HalfOpen, merged[0].Boundary, "Expected the merged boundary to follow its edges")
+	assert.Equal(t, -1, merged[0].Index, "Expected merged occurrences to not refer to a period")
+	assert.Equal(t, from.Add(21*time.Hour), merged[1].End, "Expected open occurrences sharing an excluded edge to not be merged")
+	assert.Equal(t, from.Add(21*time.Hour), merged[2].Start, "Expected open occurrences sharing an excluded edge to not be merged")
+
+	c.Periods = append(c.Periods, AlwaysPeriod{})
+	schedule, _ = c.Compile()
+	merged = schedule.Merged(from, from.Add(23*time.Hour))
+	assert.Len(t, merged, 1, "Expected an always period to absorb every occurrence")
+	assert.True(t, merged[0].Unbounded(), "Expected the merged occurrence to be unbounded")
+}
+
+func TestScheduleMergedMatchContains(t *testing.T) {
+	from := time.Date(2025, 5, 5, 0, 0, 0, 0, time.UTC)
+	for _, periods := range [][]Period{
+		{
+			OncePeriod{From: TimestampEdge{Timestamp: from.Add(10 * time.Hour)}, To: TimestampEdge{Timestamp: from.Add(12 * time.Hour)}, Boundary: Open},
+			OncePeriod{From: TimestampEdge{Timestamp: from.Add(11 * time.Hour)}, To: TimestampEdge{Timestamp: from.Add(13 * time.Hour)}, Boundary: Closed},
+		},
+		{
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "12:00"}, Boundary: Open},
+			DailyPeriod{From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "14:00"}, Boundary: OpenClosed},
+			DailyPeriod{From: TimeEdge{Hour: "14:00"}, To: TimeEdge{Hour: "16:00"}, Boundary: HalfOpen},
+		},
+		{
+			DailyPeriod{From: TimeEdge{Hour: "08:00"}, To: TimeEdge{Hour: "10:00"}, Boundary: HalfOpen},
+			DailyPeriod{From: TimeEdge{Hour: "08:00"}, To: TimeEdge{Hour: "10:00"}, Boundary: OpenClosed},
+		},
+	} {
+		c := Casoncelli{Periods: periods}
+		schedule, err := c.Compile()
+		assert.NoError(t, err, "Expected the schedule to compile")
+
+		merged := schedule.Merged(from, from.Add(24*time.Hour))
+		for _, m := range merged {
+			for _, edge := range []time.Time{m.Start, m.End} {
+				for _, at := range []time.Time{edge.Add(-time.Nanosecond), edge, edge.Add(time.Nanosecond)} {
+					contained := false
+					for _, occ := range merged {
+						contained = contained || occ.Contains(at)
+					}
+					assert.Equal(t, schedule.Contains(at), contained, "Expected the merged occurrences to match Contains at %s", at.Format(time.RFC3339Nano))
+				}
+			}
+		}
+	}
+}
+
+func TestScheduleCurrentEnd(t *testing.T) {
+	now := time.Date(2025, 5, 5, 16, 0, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "work"}, From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}},
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "overtime"}, From: TimeEdge{Hour: "16:30"}, To: TimeEdge{Hour: "19:00"}},
+	}}
+	schedule, _ := c.Compile()
+
+	end, ok := schedule.CurrentEnd(now)
+	assert.True(t, ok, "Expected the current end to be found")
+	assert.Equal(t, now.Add(3*time.Hour), end, "Expected the end of the merged occurrence")
+
+	active := schedule.Active(now)
+	assert.Len(t, active, 1, "Expected a single active occurrence")
+	assert.Equal(t, "work", active[0].Label.Name, "Expected the active occurrence of the work period")
+	assert.Len(t, schedule.Active(now.Add(45*time.Minute)), 2, "Expected both occurrences to be active")
+
+	_, ok = schedule.CurrentEnd(now.Add(4 * time.Hour))
+	assert.False(t, ok, "Expected no current end outside the periods")
+
+	// a chain of occurrences covering every day never ends
+	c.Periods = append(c.Periods, DailyPeriod{From: TimeEdge{Hour: "19:00"}, To: TimeEdge{Hour: "09:00"}})
+	schedule, _ = c.Compile()
+	_, ok = schedule.CurrentEnd(now)
+	assert.False(t, ok, "Expected no end for a schedule that is always active")
+
+	c = Casoncelli{Periods: []Period{WeeklyPeriod{
+		From: DayTimeEdge{Day: time.Monday, Hour: "00:00"},
+		To:   DayTimeEdge{Day: time.Monday, Hour: "00:00"},
+	}, OncePeriod{
+		From: TimestampEdge{Timestamp: now},
+		To:   TimestampEdge{Timestamp: now.AddDate(0, 1, 0)},
+	}}}
+	schedule, _ = c.Compile()
+	end, ok = schedule.CurrentEnd(now)
+	assert.True(t, ok, "Expected the end of a long occurrence to be found")
+	assert.Equal(t, now.AddDate(0, 1, 0), end, "Expected the end of the once period")
+}
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2025, 5, 5, 16, 0, 0, 0, time.UTC)
+	far := now.AddDate(0, 3, 0)
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "work"}, From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}},
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "overtime"}, From: TimeEdge{Hour: "16:30"}, To: TimeEdge{Hour: "19:00"}},
+	}}
+	schedule, _ := c.Compile()
+
+	next := schedule.Next(now, 3)
+	assert.Len(t, next, 3, "Expected the requested number of occurrences")
+	assert.Equal(t, "work", next[0].Label.Name, "Expected the active occurrence first")
+	assert.Equal(t, now.Add(30*time.Minute), next[1].Start, "Expected the overtime next")
+	assert.Equal(t, now.Add(17*time.Hour), next[2].Start, "Expected the work of the next day last")
+
+	merged := schedule.NextMerged(now, 2)
+	assert.Len(t, merged, 2, "Expected the requested number of merged occurrences")
+	assert.Equal(t, now.Add(3*time.Hour), merged[0].End, "Expected the current merged occurrence first")
+	assert.Equal(t, now.Add(17*time.Hour), merged[1].Start, "Expected the next merged occurrence last")
+
+	c = Casoncelli{Periods: []Period{OncePeriod{
+		From: TimestampEdge{Timestamp: far},
+		To:   TimestampEdge{Timestamp: far.Add(time.Hour)},
+	}}}
+	schedule, _ = c.Compile()
+	next = schedule.Next(now, 5)
+	assert.Len(t, next, 1, "Expected the only occurrence")
+	assert.Equal(t, far, next[0].Start, "Expected an occurrence months ahead to be found")
+}
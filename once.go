@@ -6,14 +6,25 @@ import (
 	"time"
 )
 
+const timestampLayout = "2006-01-02 15:04:05"
+
 type OncePeriod struct {
 	PeriodLabel
-	From TimestampEdge `json:"from"`
-	To   TimestampEdge `json:"to"`
+	From     TimestampEdge `json:"from"`
+	To       TimestampEdge `json:"to"`
+	Boundary Boundary      `json:"boundary,omitempty"`
+}
+
+func (p OncePeriod) MarshalJSON() ([]byte, error) {
+	type once OncePeriod
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		once
+	}{"once", once(p)})
 }
 
 func (p OncePeriod) Contains(t time.Time) bool {
-	return p.From.BeforeOrEqual(t) && p.To.AfterOrEqual(t)
+	return p.Boundary.afterStart(p.From, t) && p.Boundary.beforeEnd(p.To, t)
 }
 
 func (p OncePeriod) ContainsNow() bool {
@@ -66,6 +77,21 @@ func (p OncePeriod) PreviousEnd() (*time.Time, error) {
 	return nil, fmt.Errorf("no previous occurrence for once period")
 }
 
+func (p OncePeriod) validate() error {
+	if err := p.Boundary.validate(); err != nil {
+		return err
+	}
+	if p.To.Before(p.From.Timestamp) {
+		return fmt.Errorf("period ends before it starts")
+	}
+	return nil
+}
+
+func (p OncePeriod) withDefaultBoundary(b Boundary) Period {
+	p.Boundary = p.Boundary.or(b)
+	return p
+}
+
 type TimestampEdge struct {
 	Timestamp time.Time `json:"timestamp"`
 }
@@ -78,7 +104,7 @@ func (t *TimestampEdge) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	ts, err := time.ParseInLocation("2006-01-02 15:04:05", aux.Timestamp, time.Local)
+	ts, err := time.ParseInLocation(timestampLayout, aux.Timestamp, time.Local)
 
 	if err != nil {
 		return err
@@ -87,6 +113,12 @@ func (t *TimestampEdge) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (t TimestampEdge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Timestamp string `json:"timestamp"`
+	}{t.Timestamp.In(time.Local).Format(timestampLayout)})
+}
+
 func (e TimestampEdge) Before(t time.Time) bool {
 	return e.Timestamp.Before(t)
 }
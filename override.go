@@ -0,0 +1,284 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Override forces a schedule active, or inactive, from From until Until,
+// taking precedence over its periods. Overrides are meant for incidents:
+// they expire on their own, and are saved along with the periods, with the
+// times in the local layout of the timestamps of the once periods.
+type Override struct {
+	// ID identifies the override, and must be unique within a Casoncelli when set.
+	ID string `json:"id,omitempty"`
+	// Active forces the schedule active when true, and inactive when false.
+	Active bool      `json:"active"`
+	From   time.Time `json:"from"`
+	Until  time.Time `json:"until"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// overrideJSON is the JSON format of an Override, whose times are written
+// like the timestamps of the periods.
+type overrideJSON struct {
+	ID     string `json:"id,omitempty"`
+	Active bool   `json:"active"`
+	From   string `json:"from"`
+	Until  string `json:"until"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (o Override) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.toJSON())
+}
+
+func (o *Override) UnmarshalJSON(data []byte) error {
+	var aux overrideJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	return o.fromJSON(aux)
+}
+
+func (o Override) toJSON() overrideJSON {
+	return overrideJSON{
+		ID:     o.ID,
+		Active: o.Active,
+		From:   formatOverrideTime(o.From),
+		Until:  formatOverrideTime(o.Until),
+		Reason: o.Reason,
+	}
+}
+
+func (o *Override) fromJSON(aux overrideJSON) error {
+	from, err := parseOverrideTime(aux.From)
+	if err != nil {
+		return err
+	}
+	until, err := parseOverrideTime(aux.Until)
+	if err != nil {
+		return err
+	}
+	*o = Override{ID: aux.ID, Active: aux.Active, From: from, Until: until, Reason: aux.Reason}
+	return nil
+}
+
+// formatOverrideTime formats t in the local layout of the timestamps, or as
+// an empty string when t is zero.
+func formatOverrideTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Local).Format(timestampLayout)
+}
+
+// parseOverrideTime parses a time formatted by formatOverrideTime.
+func parseOverrideTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation(timestampLayout, s, time.Local)
+}
+
+// Covers reports whether t is in the half-open range [From, Until).
+func (o Override) Covers(t time.Time) bool {
+	return !t.Before(o.From) && t.Before(o.Until)
+}
+
+// Expired reports whether the override ended at or before t.
+func (o Override) Expired(t time.Time) bool {
+	return !t.Before(o.Until)
+}
+
+func (o Override) validate() error {
+	if o.From.IsZero() || o.Until.IsZero() {
+		return errors.New("missing from or until")
+	}
+	if !o.Until.After(o.From) {
+		return fmt.Errorf("until %s is not after from %s", o.Until.Format(time.RFC3339), o.From.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// overrideAt returns the override in effect at t, the last one covering it.
+func overrideAt(overrides []Override, t time.Time) (Override, bool) {
+	for i := len(overrides) - 1; i >= 0; i-- {
+		if overrides[i].Covers(t) {
+			return overrides[i], true
+		}
+	}
+	return Override{}, false
+}
+
+// OverrideAt returns the override in effect at t: when several overrides
+// cover t, the last one wins.
+func (c *Casoncelli) OverrideAt(t time.Time) (Override, bool) {
+	return overrideAt(c.Overrides, t)
+}
+
+// OverrideAt returns the override in effect at t: when several overrides
+// cover t, the last one wins.
+func (s *Schedule) OverrideAt(t time.Time) (Override, bool) {
+	return overrideAt(s.source.Overrides, t)
+}
+
+// Explanation tells why a time is or is not contained in a Schedule.
+type Explanation struct {
+	Time      time.Time
+	Contained bool
+	// Occurrences are the occurrences of the periods containing Time, sorted
+	// by start, whether or not an override is in effect.
+	Occurrences []Occurrence
+	// Override is the override in effect at Time, if any. It decides Contained
+	// regardless of the Occurrences.
+	Override *Override
+}
+
+// Explain returns the periods and the override deciding whether t is
+// contained in the schedule.
+func (s *Schedule) Explain(t time.Time) Explanation {
+	e := Explanation{Time: t, Contained: s.Contains(t), Occurrences: s.Active(t)}
+	if o, ok := s.OverrideAt(t); ok {
+		e.Override = &o
+	}
+	return e
+}
+
+// overridden applies the overrides overlapping [from, to] to the merged
+// occurrences, in order, so that the last one wins.
+func (s *Schedule) overridden(merged []Occurrence, from, to time.Time) []Occurrence {
+	for _, o := range s.source.Overrides {
+		if o.Until.Before(from) || o.From.After(to) {
+			continue
+		}
+		window := Occurrence{Start: o.From, End: o.Until, Boundary: HalfOpen, Index: -1}
+		if o.Active {
+			merged = append(merged, window)
+			slices.SortFunc(merged, compareOccurrences)
+			merged = merge(merged)
+		} else {
+			merged = subtract(merged, window, from, to)
+		}
+	}
+	return merged
+}
+
+// subtract removes the bounded window from the occurrences, honoring the
+// boundaries of both. An unbounded occurrence cannot be cut, so it is first
+// bounded to [from, to] when the window overlaps that range.
+func subtract(occurrences []Occurrence, window Occurrence, from, to time.Time) []Occurrence {
+	var result []Occurrence
+	windowStartIn, windowEndIn := window.Boundary.includesStart(), window.Boundary.includesEnd()
+	for _, occ := range occurrences {
+		if occ.Unbounded() {
+			if window.End.Before(from) || window.Start.After(to) {
+				result = append(result, occ)
+				continue
+			}
+			occ = Occurrence{Start: from, End: to, Boundary: Closed, Index: occ.Index, Label: occ.Label}
+		}
+		startIn, endIn := occ.Boundary.includesStart(), occ.Boundary.includesEnd()
+		if occ.Start.Before(window.Start) || (occ.Start.Equal(window.Start) && startIn && !windowStartIn) {
+			before := occ
+			if occ.End.After(window.Start) || (occ.End.Equal(window.Start) && endIn && windowStartIn) {
+				before.End, before.Boundary = window.Start, boundaryOf(startIn, !windowStartIn)
+			}
+			result = append(result, before)
+		}
+		if occ.End.After(window.End) || (occ.End.Equal(window.End) && endIn && !windowEndIn) {
+			after := occ
+			if occ.Start.Before(window.End) || (occ.Start.Equal(window.End) && startIn && windowEndIn) {
+				after.Start, after.Boundary = window.End, boundaryOf(!windowEndIn, endIn)
+			}
+			result = append(result, after)
+		}
+	}
+	return result
+}
+
+// overrideOccurrence returns the occurrence standing for the active override
+// o, which does not refer to a period.
+func overrideOccurrence(o Override) Occurrence {
+	return Occurrence{Start: o.From, End: o.Until, Boundary: HalfOpen, Index: -1, Label: PeriodLabel{ID: o.ID, Description: o.Reason}}
+}
+
+// withOverrides adds to the occurrences of the periods the ones of the
+// active overrides overlapping [from, to], sorted by start.
+func (s *Schedule) withOverrides(occurrences []Occurrence, from, to time.Time) []Occurrence {
+	for _, o := range s.source.Overrides {
+		if o.Active && !o.Until.Before(from) && !o.From.After(to) {
+			occurrences = append(occurrences, overrideOccurrence(o))
+		}
+	}
+	slices.SortFunc(occurrences, compareOccurrences)
+	return occurrences
+}
+
+// inEffect reports whether occ, an occurrence of a period or of an active
+// override, is in [Start, End) at t and not superseded by an override: the
+// occurrences of the periods yield to the inactive overrides, and the ones
+// of the overrides to the overrides added after them.
+func (s *Schedule) inEffect(occ Occurrence, t time.Time) bool {
+	if !occ.Unbounded() && (t.Before(occ.Start) || !t.Before(occ.End)) {
+		return false
+	}
+	o, ok := s.OverrideAt(t)
+	if occ.Index < 0 {
+		return ok && o.ID == occ.Label.ID && o.From.Equal(occ.Start) && o.Until.Equal(occ.End)
+	}
+	return !ok || o.Active
+}
+
+// transitionsOf returns the times in (from, to] when occ starts being in
+// effect, for Enter, or stops being in effect, for Exit.
+func (s *Schedule) transitionsOf(occ Occurrence, kind EventKind, from, to time.Time) []time.Time {
+	candidates := []time.Time{occ.Start, occ.End}
+	for _, o := range s.source.Overrides {
+		candidates = append(candidates, o.From, o.Until)
+	}
+	slices.SortFunc(candidates, time.Time.Compare)
+	candidates = slices.CompactFunc(candidates, time.Time.Equal)
+
+	var result []time.Time
+	for _, t := range candidates {
+		if !t.After(from) || t.After(to) {
+			continue
+		}
+		before, after := s.inEffect(occ, t.Add(-time.Nanosecond)), s.inEffect(occ, t)
+		if (kind == Enter && after && !before) || (kind == Exit && before && !after) {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// Override adds o to the overrides of the current schedule, dropping the
+// ones already expired, and returns the new version.
+func (l *Live) Override(o Override) (uint64, error) {
+	now := clockOrSystem(l.Clock).Now()
+	return l.update(func(c Casoncelli, _ uint64) (Casoncelli, error) {
+		c.Overrides = append(slices.DeleteFunc(c.Overrides, func(e Override) bool {
+			return e.Expired(now)
+		}), o)
+		return c, nil
+	})
+}
+
+// RemoveOverride deletes the override with the given id from the current
+// schedule, ending it before its time, and returns the new version.
+func (l *Live) RemoveOverride(id string) (uint64, error) {
+	return l.update(func(c Casoncelli, _ uint64) (Casoncelli, error) {
+		n := len(c.Overrides)
+		c.Overrides = slices.DeleteFunc(c.Overrides, func(o Override) bool {
+			return o.ID == id
+		})
+		if len(c.Overrides) == n {
+			return c, fmt.Errorf("no override with id %s", id)
+		}
+		return c, nil
+	})
+}
@@ -0,0 +1,219 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOverrideContains(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Reason: "database failover"},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	for _, tc := range []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2025, 5, 6, 2, 0, 0, 0, time.UTC), false},
+		{time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), true},
+		{time.Date(2025, 5, 6, 9, 59, 0, 0, time.UTC), false},
+		{time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), false},
+		{time.Date(2025, 5, 7, 2, 0, 0, 0, time.UTC), true},
+	} {
+		assert.Equal(t, tc.expected, c.Contains(tc.at), "Expected the Casoncelli to contain %s: %v", tc.at, tc.expected)
+		assert.Equal(t, tc.expected, s.Contains(tc.at), "Expected the Schedule to contain %s: %v", tc.at, tc.expected)
+	}
+
+	// the last override wins
+	c.Overrides = append(c.Overrides, Override{From: time.Date(2025, 5, 6, 10, 30, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 11, 0, 0, 0, time.UTC)})
+	assert.False(t, c.Contains(time.Date(2025, 5, 6, 10, 40, 0, 0, time.UTC)), "Expected the last override to win")
+}
+
+func TestOverrideMerged(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Reason: "database failover"},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	merged := s.Merged(time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, []Occurrence{
+		{Start: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), End: time.Date(2025, 5, 6, 3, 0, 0, 0, time.UTC), Boundary: Closed, Index: -1},
+		{Start: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), End: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Boundary: HalfOpen, Index: -1},
+	}, merged, "Expected the maintenance to be cut, and the incident added")
+	assert.Len(t, s.Occurrences(time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC), time.Date(2025, 5, 7, 0, 0, 0, 0, time.UTC)), 1, "Expected the occurrences to ignore the overrides")
+
+	end, ok := s.CurrentEnd(time.Date(2025, 5, 6, 10, 10, 0, 0, time.UTC))
+	assert.True(t, ok, "Expected the end of the incident")
+	assert.Equal(t, time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), end, "Expected the incident to end with its override")
+
+	next := s.NextMerged(time.Date(2025, 5, 6, 1, 30, 0, 0, time.UTC), 1)
+	assert.Equal(t, time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), next[0].Start, "Expected the next start after the forced inactive override")
+
+	// an always period is cut within the range of the search
+	always := Casoncelli{Periods: []Period{AlwaysPeriod{}}, Overrides: c.Overrides[:1]}
+	s, err = always.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+	end, ok = s.CurrentEnd(time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC))
+	assert.True(t, ok, "Expected the always period to end with the override")
+	assert.Equal(t, time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), end, "Expected the start of the override")
+}
+
+func TestOverrideEvaluate(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Reason: "database failover"},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	var ts []time.Time
+	for at := time.Date(2025, 5, 6, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2025, 5, 7, 4, 0, 0, 0, time.UTC)); at = at.Add(5 * time.Minute) {
+		ts = append(ts, at)
+	}
+	for i, m := range s.Evaluate(ts) {
+		assert.Equal(t, s.Contains(ts[i]), m.Contained, "Expected the membership at %s to match Contains", ts[i])
+		o, ok := s.OverrideAt(ts[i])
+		assert.Equal(t, ok, m.Override != nil, "Expected the override at %s", ts[i])
+		if ok {
+			assert.Equal(t, o.ID, m.Override.ID, "Expected the override at %s", ts[i])
+		}
+	}
+
+	m := s.Evaluate([]time.Time{time.Date(2025, 5, 6, 2, 15, 0, 0, time.UTC)})[0]
+	assert.False(t, m.Contained, "Expected the maintenance to be forced off")
+	assert.Equal(t, 0, m.Index, "Expected the period to be reported anyway")
+}
+
+func TestOverrideExplain(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Reason: "database failover"},
+		},
+	}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	e := s.Explain(time.Date(2025, 5, 6, 2, 15, 0, 0, time.UTC))
+	assert.False(t, e.Contained, "Expected the maintenance to be forced off")
+	assert.Len(t, e.Occurrences, 1, "Expected the matching period")
+	assert.Equal(t, "skip", e.Override.ID, "Expected the override deciding")
+
+	e = s.Explain(time.Date(2025, 5, 6, 12, 0, 0, 0, time.UTC))
+	assert.False(t, e.Contained, "Expected the schedule to be inactive")
+	assert.Empty(t, e.Occurrences, "Expected no matching period")
+	assert.Nil(t, e.Override, "Expected no override")
+}
+
+func TestOverrideValidate(t *testing.T) {
+	from := time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		overrides []Override
+		expected  string
+	}{
+		{[]Override{{Until: from}}, "override 0: missing from or until"},
+		{[]Override{{From: from, Until: from}}, "override 0: until 2025-05-06T10:00:00Z is not after from 2025-05-06T10:00:00Z"},
+		{[]Override{{ID: "a", From: from, Until: from.Add(time.Hour)}, {ID: "a", From: from, Until: from.Add(time.Hour)}}, `override 1: id "a" is already the one of override 0`},
+	} {
+		c := Casoncelli{Overrides: tc.overrides}
+		assert.EqualError(t, c.Validate(), tc.expected, "Expected the overrides to be refused")
+	}
+}
+
+func TestOverrideJSON(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.Local), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.Local), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.Local), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.Local), Reason: "database failover"},
+		},
+	}
+	data, err := json.Marshal(c)
+	assert.NoError(t, err, "Expected the schedule to be marshaled")
+	assert.Contains(t, string(data), `{"id":"skip","active":false,"from":"2025-05-06 01:00:00","until":"2025-05-06 02:30:00","reason":"release"}`, "Expected the times in the layout of the timestamps")
+
+	var decoded Casoncelli
+	assert.NoError(t, json.Unmarshal(data, &decoded), "Expected the schedule to be unmarshaled")
+	assert.Equal(t, c.Overrides, decoded.Overrides, "Expected the overrides to survive a round trip")
+
+	data, err = json.Marshal(Casoncelli{Periods: []Period{}})
+	assert.NoError(t, err, "Expected the schedule to be marshaled")
+	assert.JSONEq(t, `{"periods": []}`, string(data), "Expected no overrides to be omitted")
+}
+
+func TestLiveOverride(t *testing.T) {
+	c := Casoncelli{
+		Periods: []Period{
+			DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		},
+		Overrides: []Override{
+			{ID: "skip", From: time.Date(2025, 5, 6, 1, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC), Reason: "release"},
+			{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 10, 45, 0, 0, time.UTC), Reason: "database failover"},
+		},
+	}
+	live, err := NewLive(c)
+	assert.NoError(t, err, "Expected the schedule to compile")
+	live.Clock = newFakeClock(time.Date(2025, 5, 6, 10, 15, 0, 0, time.UTC))
+
+	// the forced inactive override is expired by now
+	from := time.Date(2025, 5, 6, 10, 30, 0, 0, time.UTC)
+	version, err := live.Override(Override{ID: "extended", Active: true, From: from, Until: from.Add(45 * time.Minute)})
+	assert.NoError(t, err, "Expected the override to be added")
+	assert.Equal(t, uint64(2), version, "Expected a new version")
+	ids := []string{}
+	for _, o := range live.Schedule().Casoncelli().Overrides {
+		ids = append(ids, o.ID)
+	}
+	assert.Equal(t, []string{"incident", "extended"}, ids, "Expected the expired override to be dropped")
+	assert.True(t, live.Contains(time.Date(2025, 5, 6, 11, 0, 0, 0, time.UTC)), "Expected the schedule to be forced active")
+
+	_, err = live.Override(Override{From: from, Until: from})
+	assert.Error(t, err, "Expected an invalid override to be refused")
+
+	_, err = live.RemoveOverride("extended")
+	assert.NoError(t, err, "Expected the override to be removed")
+	assert.False(t, live.Contains(time.Date(2025, 5, 6, 11, 0, 0, 0, time.UTC)), "Expected the schedule to be back to its periods")
+	_, err = live.RemoveOverride("extended")
+	assert.EqualError(t, err, "no override with id extended", "Expected a missing override to be reported")
+}
+
+func TestLiveOverrideKeepsCurrent(t *testing.T) {
+	live := newTestLive(t)
+	live.Clock = newFakeClock(time.Date(2025, 5, 6, 10, 15, 0, 0, time.UTC))
+	_, err := live.Override(Override{ID: "incident", Active: true, From: time.Date(2025, 5, 6, 10, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 11, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err, "Expected the override to be added")
+
+	_, err = live.Override(Override{ID: "release", Active: true, From: time.Date(2025, 5, 6, 12, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 13, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err, "Expected the override to be added")
+	assert.Len(t, live.Schedule().Casoncelli().Overrides, 2, "Expected the override in effect to be kept")
+	assert.True(t, live.Contains(time.Date(2025, 5, 6, 10, 30, 0, 0, time.UTC)), "Expected the override in effect to still force the schedule active")
+}
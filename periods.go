@@ -16,8 +16,27 @@ type Period interface {
 }
 
 type PeriodLabel struct {
+	// ID identifies the period, and must be unique within a Casoncelli when set.
+	ID          string `json:"id,omitempty"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	// Priority orders the periods containing the same time: the highest
+	// priority decides whether the time is included, following its Effect.
+	Priority int    `json:"priority,omitempty"`
+	Effect   Effect `json:"effect,omitempty"`
+}
+
+// Label returns the label itself, making it reachable from the periods embedding it.
+func (l PeriodLabel) Label() PeriodLabel {
+	return l
+}
+
+// labelOf returns the label of the period, if it has one.
+func labelOf(p Period) PeriodLabel {
+	if l, ok := p.(interface{ Label() PeriodLabel }); ok {
+		return l.Label()
+	}
+	return PeriodLabel{}
 }
 
 type Edge interface {
@@ -27,3 +46,8 @@ type Edge interface {
 	BeforeOrEqual(time.Time) bool
 	AfterOrEqual(time.Time) bool
 }
+
+// validator is implemented by the periods that can check their own definition.
+type validator interface {
+	validate() error
+}
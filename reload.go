@@ -0,0 +1,160 @@
+package casoncelli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultReloadInterval is the polling interval of a FileLoader without one.
+const DefaultReloadInterval = 5 * time.Second
+
+// FileLoader keeps a Live in sync with a JSON schedule file.
+//
+// The file is polled for changes to its modification time or size; changed
+// contents are parsed and validated, and replace the schedule only if they
+// are valid. Otherwise the last good schedule is kept and the error is
+// reported through OnError. Contents equal to the live schedule, like the
+// ones saved by a FileStore, leave it and its version alone.
+type FileLoader struct {
+	// Path is the path of the schedule file.
+	Path string
+	// Live receives the schedules loaded from the file.
+	Live *Live
+	// Interval is the polling interval, DefaultReloadInterval if zero.
+	Interval time.Duration
+	// ReloadOnSIGHUP makes Run reload the file when the process receives a SIGHUP.
+	ReloadOnSIGHUP bool
+	// OnError, if set, is called by Run with the errors met while reloading.
+	OnError func(error)
+	// OnReload, if set, is called with the new version after every reload
+	// that changed the schedule.
+	OnReload func(version uint64)
+
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	loaded  bool
+	err     error
+}
+
+// Load reads the file and replaces the schedule if its contents changed since
+// the last load.
+func (f *FileLoader) Load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	return f.load(info)
+}
+
+// Check reloads the file if its modification time or size changed since the
+// last load.
+func (f *FileLoader) Check() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return err
+	}
+	if f.loaded && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return nil
+	}
+	return f.load(info)
+}
+
+func (f *FileLoader) load(info os.FileInfo) error {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return err
+	}
+
+	// the file is not parsed again until it changes, even if it is invalid
+	f.modTime, f.size, f.loaded = info.ModTime(), info.Size(), true
+	hash := sha256.Sum256(data)
+	if hash == f.hash {
+		return f.err
+	}
+	f.hash = hash
+
+	f.err = f.replace(data)
+	return f.err
+}
+
+func (f *FileLoader) replace(data []byte) error {
+	var c Casoncelli
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if f.current(c) {
+		return nil
+	}
+	version, err := f.Live.Replace(c)
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if f.OnReload != nil {
+		f.OnReload(version)
+	}
+	return nil
+}
+
+// current reports whether c is the schedule already live, like after a
+// FileStore saved the changes of an Admin to the file.
+func (f *FileLoader) current(c Casoncelli) bool {
+	live := f.Live.Schedule().Casoncelli()
+	a, err := json.Marshal(c)
+	if err != nil {
+		return false
+	}
+	b, err := json.Marshal(live)
+	return err == nil && bytes.Equal(a, b)
+}
+
+// Run loads the file and keeps polling it until ctx is done, returning the
+// context error.
+func (f *FileLoader) Run(ctx context.Context) error {
+	var hup chan os.Signal
+	if f.ReloadOnSIGHUP {
+		hup = make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		defer signal.Stop(hup)
+	}
+
+	interval := f.Interval
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	f.report(f.Load())
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			f.report(f.Check())
+		case <-hup:
+			f.report(f.Load())
+		}
+	}
+}
+
+func (f *FileLoader) report(err error) {
+	if err != nil && f.OnError != nil {
+		f.OnError(err)
+	}
+}
@@ -0,0 +1,110 @@
+package casoncelli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const alwaysJson = `{"periods":[{"name":"maintenance","type":"always"}]}`
+const neverJson = `{"periods":[{"name":"placeholder","type":"never"}]}`
+
+func writeSchedule(t *testing.T, path, content string, modTime time.Time) {
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o644), "Expected the schedule file to be written")
+	assert.NoError(t, os.Chtimes(path, modTime, modTime), "Expected the schedule file times to be set")
+}
+
+func TestFileLoaderLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	base := time.Now().Add(-time.Hour)
+	writeSchedule(t, path, alwaysJson, base)
+
+	loader := &FileLoader{Path: path, Live: &Live{}}
+	assert.NoError(t, loader.Load(), "Expected no error loading a valid file")
+	assert.True(t, loader.Live.ContainsNow(), "Expected the loaded schedule to be active")
+	assert.Equal(t, uint64(1), loader.Live.Version(), "Expected the schedule to be replaced")
+
+	assert.NoError(t, loader.Load(), "Expected no error loading an unchanged file")
+	assert.Equal(t, uint64(1), loader.Live.Version(), "Expected an unchanged file to not replace the schedule")
+
+	writeSchedule(t, path, `{"periods":[{"type":"daily","from":{"hour":"9"},"to":{"hour":"10:00"}}]}`, base.Add(time.Minute))
+	assert.Error(t, loader.Check(), "Expected an error checking an invalid file")
+	assert.True(t, loader.Live.ContainsNow(), "Expected the last good schedule to be kept")
+	assert.NoError(t, loader.Check(), "Expected an invalid file to be reported once")
+	assert.Error(t, loader.Load(), "Expected an explicit load to report the invalid file again")
+
+	writeSchedule(t, path, `{"periods":[`, base.Add(2*time.Minute))
+	assert.Error(t, loader.Check(), "Expected an error checking a malformed file")
+	assert.Equal(t, uint64(1), loader.Live.Version(), "Expected the last good schedule to be kept")
+
+	writeSchedule(t, path, neverJson, base.Add(3*time.Minute))
+	assert.NoError(t, loader.Check(), "Expected no error checking a fixed file")
+	assert.False(t, loader.Live.ContainsNow(), "Expected the fixed schedule to be loaded")
+	assert.Equal(t, uint64(2), loader.Live.Version(), "Expected the schedule to be replaced")
+}
+
+func TestFileLoaderCheckSkipsUnchangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	base := time.Now().Add(-time.Hour)
+	writeSchedule(t, path, alwaysJson, base)
+
+	loader := &FileLoader{Path: path, Live: &Live{}}
+	assert.NoError(t, loader.Check(), "Expected no error on the first check")
+
+	// same size and modification time: the change is not noticed by polling
+	writeSchedule(t, path, sameSizeNever(alwaysJson), base)
+	assert.NoError(t, loader.Check(), "Expected no error checking an untouched file")
+	assert.True(t, loader.Live.ContainsNow(), "Expected polling to skip a file with the same size and time")
+
+	assert.NoError(t, loader.Load(), "Expected no error forcing a reload")
+	assert.False(t, loader.Live.ContainsNow(), "Expected a forced reload to read the file")
+}
+
+// sameSizeNever returns the always schedule turned into a never one of the same size.
+func sameSizeNever(s string) string {
+	return s[:len(s)-len(`"always"}]}`)] + `"never" }]}`
+}
+
+func TestFileLoaderMissingFile(t *testing.T) {
+	loader := &FileLoader{Path: filepath.Join(t.TempDir(), "missing.json"), Live: &Live{}}
+	assert.Error(t, loader.Load(), "Expected an error loading a missing file")
+	assert.Error(t, loader.Check(), "Expected an error checking a missing file")
+}
+
+func TestFileLoaderRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	base := time.Now().Add(-time.Hour)
+	writeSchedule(t, path, alwaysJson, base)
+
+	var reloads, errors atomic.Int32
+	loader := &FileLoader{
+		Path:     path,
+		Live:     &Live{},
+		Interval: 5 * time.Millisecond,
+		OnReload: func(uint64) { reloads.Add(1) },
+		OnError:  func(error) { errors.Add(1) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- loader.Run(ctx) }()
+
+	assert.Eventually(t, func() bool { return reloads.Load() == 1 }, time.Second, time.Millisecond, "Expected the file to be loaded")
+	assert.True(t, loader.Live.ContainsNow(), "Expected the loaded schedule to be active")
+
+	writeSchedule(t, path, `not json`, base.Add(time.Minute))
+	assert.Eventually(t, func() bool { return errors.Load() == 1 }, time.Second, time.Millisecond, "Expected the invalid file to be reported")
+
+	writeSchedule(t, path, neverJson, base.Add(2*time.Minute))
+	assert.Eventually(t, func() bool { return reloads.Load() == 2 }, time.Second, time.Millisecond, "Expected the fixed file to be loaded")
+	assert.False(t, loader.Live.ContainsNow(), "Expected the fixed schedule to be active")
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled, "Expected Run to return the context error")
+	assert.Equal(t, int32(1), errors.Load(), "Expected the invalid file to be reported once")
+}
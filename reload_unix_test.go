@@ -0,0 +1,39 @@
+//go:build unix
+
+package casoncelli
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLoaderReloadOnSIGHUP(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	base := time.Now().Add(-time.Hour)
+	writeSchedule(t, path, alwaysJson, base)
+
+	var reloads atomic.Int32
+	loader := &FileLoader{
+		Path:           path,
+		Live:           &Live{},
+		Interval:       time.Hour,
+		ReloadOnSIGHUP: true,
+		OnReload:       func(uint64) { reloads.Add(1) },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loader.Run(ctx)
+	assert.Eventually(t, func() bool { return reloads.Load() == 1 }, time.Second, time.Millisecond, "Expected the file to be loaded")
+
+	writeSchedule(t, path, neverJson, base.Add(time.Minute))
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGHUP), "Expected the signal to be sent")
+	assert.Eventually(t, func() bool { return reloads.Load() == 2 }, time.Second, time.Millisecond, "Expected the signal to reload the file")
+	assert.False(t, loader.Live.ContainsNow(), "Expected the reloaded schedule to be active")
+}
@@ -0,0 +1,147 @@
+package casoncelli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultHistorySize is the number of runs kept by a Runner without a HistorySize.
+const DefaultHistorySize = 100
+
+// JobPolicy tells what happens to a running job when its allowed time ends.
+type JobPolicy int
+
+const (
+	// CancelJob cancels the context of the job when its allowed time ends.
+	CancelJob JobPolicy = iota
+	// FinishJob lets the job finish after its allowed time ends.
+	FinishJob
+)
+
+func (p JobPolicy) String() string {
+	switch p {
+	case CancelJob:
+		return "cancel"
+	case FinishJob:
+		return "finish"
+	default:
+		return fmt.Sprintf("JobPolicy(%d)", int(p))
+	}
+}
+
+// Job is a task run by a Runner only at the allowed times: while the
+// schedule is not active, like outside of a blackout, or only while it is
+// active with Inside, like during a nightly window.
+type Job struct {
+	// Name identifies the job in the run history.
+	Name string
+	// Func is the task. Its context is canceled when the allowed time ends,
+	// unless Policy is FinishJob.
+	Func func(ctx context.Context) error
+	// Inside allows the job only while the schedule is active.
+	Inside bool
+	// Policy tells what happens when the allowed time ends during a run.
+	Policy JobPolicy
+	// MaxAttempts is the number of runs after which a failing job is given
+	// up, no limit if zero.
+	MaxAttempts int
+}
+
+// JobRun is a run of a Job.
+type JobRun struct {
+	Job     string
+	Attempt int
+	Start   time.Time
+	End     time.Time
+	// Err is the error returned by the job.
+	Err error
+	// Interrupted reports whether the job was canceled because its allowed
+	// time ended.
+	Interrupted bool
+}
+
+// Runner runs jobs at the times allowed by a Live schedule.
+//
+// A job is started as soon as it is allowed. If it fails, or it is
+// interrupted because its allowed time ended, it is retried at the start of
+// the next allowed time, until it succeeds.
+type Runner struct {
+	// Live is the schedule of the jobs.
+	Live *Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+	// HistorySize is the number of runs kept, DefaultHistorySize if zero.
+	HistorySize int
+
+	mu      sync.Mutex
+	history []JobRun
+}
+
+// Run runs job until it succeeds, returning nil, or until ctx is done,
+// returning its cause, or until it failed MaxAttempts times, returning the
+// last error.
+func (r *Runner) Run(ctx context.Context, job Job) error {
+	clock := clockOrSystem(r.Clock)
+	// the cause of the interruption of the job at the end of its allowed time
+	ended := ErrScheduleActive
+	if job.Inside {
+		ended = ErrScheduleInactive
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := waitUntil(ctx, r.Live, clock, job.Inside); err != nil {
+			return err
+		}
+
+		run := JobRun{Job: job.Name, Attempt: attempt, Start: clock.Now()}
+		jobCtx, cancel := ctx, context.CancelFunc(func() {})
+		if job.Policy == CancelJob {
+			jobCtx, cancel = contextUntil(ctx, r.Live, clock, !job.Inside, ended)
+		}
+		run.Err = job.Func(jobCtx)
+		run.End = clock.Now()
+		run.Interrupted = ctx.Err() == nil && errors.Is(context.Cause(jobCtx), ended)
+		cancel()
+		r.record(run)
+
+		switch {
+		case ctx.Err() != nil:
+			return context.Cause(ctx)
+		case run.Err == nil && !run.Interrupted:
+			return nil
+		case job.MaxAttempts > 0 && attempt >= job.MaxAttempts:
+			if run.Err == nil {
+				run.Err = ended
+			}
+			return fmt.Errorf("job %s: giving up after %d attempts: %w", job.Name, attempt, run.Err)
+		}
+
+		// the next allowed time starts after the current one ends
+		if err := waitUntil(ctx, r.Live, clock, !job.Inside); err != nil {
+			return err
+		}
+	}
+}
+
+// History returns the most recent runs, oldest first.
+func (r *Runner) History() []JobRun {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]JobRun(nil), r.history...)
+}
+
+func (r *Runner) record(run JobRun) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	size := r.HistorySize
+	if size <= 0 {
+		size = DefaultHistorySize
+	}
+	r.history = append(r.history, run)
+	if len(r.history) > size {
+		r.history = append(r.history[:0], r.history[len(r.history)-size:]...)
+	}
+}
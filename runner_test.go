@@ -0,0 +1,128 @@
+package casoncelli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunnerOutside(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 1, 30, 0, 0, time.UTC))
+	r := &Runner{Live: newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Clock: clock}
+
+	started := make(chan int)
+	job := Job{Name: "export", Func: func(ctx context.Context) error {
+		started <- 0
+		if len(r.History()) == 0 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background(), job)
+	}()
+
+	<-started
+	clock.waitCalls(t, 1)
+	clock.Advance(30 * time.Minute)
+	clock.waitCalls(t, 2)
+	clock.Advance(time.Hour)
+	clock.waitCalls(t, 3)
+	select {
+	case <-started:
+		assert.Fail(t, "Expected the job to not run on the closed end of the blackout")
+	default:
+	}
+	clock.Advance(time.Nanosecond)
+	<-started
+	assert.NoError(t, <-done, "Expected the job to succeed on the second attempt")
+
+	history := r.History()
+	assert.Len(t, history, 2, "Expected two runs")
+	assert.True(t, history[0].Interrupted, "Expected the first run to be interrupted by the blackout")
+	assert.ErrorIs(t, history[0].Err, context.Canceled, "Expected the error of the first run")
+	assert.Equal(t, time.Date(2025, 5, 5, 2, 0, 0, 0, time.UTC), history[0].End, "Expected the first run to end at the blackout")
+	assert.Equal(t, 2, history[1].Attempt, "Expected the attempt number")
+	assert.False(t, history[1].Interrupted, "Expected the second run to complete")
+	assert.Equal(t, "export", history[1].Job, "Expected the name of the job")
+}
+
+func TestRunnerInsideFinish(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 1, 0, 0, 0, time.UTC))
+	r := &Runner{Live: newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Clock: clock}
+
+	started, release := make(chan struct{}), make(chan struct{})
+	job := Job{Name: "reindex", Inside: true, Policy: FinishJob, Func: func(ctx context.Context) error {
+		close(started)
+		<-release
+		return ctx.Err()
+	}}
+	done := make(chan error)
+	go func() {
+		done <- r.Run(context.Background(), job)
+	}()
+
+	clock.waitCalls(t, 1)
+	select {
+	case <-started:
+		assert.Fail(t, "Expected the job to wait for the window")
+	default:
+	}
+	clock.Advance(time.Hour)
+	<-started
+	clock.Advance(2 * time.Hour)
+	close(release)
+	assert.NoError(t, <-done, "Expected the job to finish after the window")
+
+	history := r.History()
+	assert.Len(t, history, 1, "Expected a single run")
+	assert.Equal(t, time.Date(2025, 5, 5, 2, 0, 0, 0, time.UTC), history[0].Start, "Expected the run to start with the window")
+	assert.Equal(t, time.Date(2025, 5, 5, 4, 0, 0, 0, time.UTC), history[0].End, "Expected the run to end after the window")
+	assert.False(t, history[0].Interrupted, "Expected the run to not be interrupted")
+}
+
+func TestRunnerMaxAttempts(t *testing.T) {
+	r := &Runner{Live: newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Clock: newFakeClock(time.Date(2025, 5, 5, 1, 30, 0, 0, time.UTC))}
+	failure := errors.New("failure")
+
+	err := r.Run(context.Background(), Job{Name: "export", MaxAttempts: 1, Func: func(ctx context.Context) error {
+		return failure
+	}})
+	assert.ErrorIs(t, err, failure, "Expected the error of the last run")
+	assert.EqualError(t, err, "job export: giving up after 1 attempts: failure", "Expected the job name in the error")
+	assert.Len(t, r.History(), 1, "Expected the failed run in the history")
+}
+
+func TestRunnerCanceled(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	r := &Runner{Live: newTestLive(t, DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}), Clock: clock}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- r.Run(ctx, Job{Func: func(ctx context.Context) error {
+			assert.Fail(t, "Expected the job to not run during the blackout")
+			return nil
+		}})
+	}()
+	clock.waitCalls(t, 1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled, "Expected the context error")
+	assert.Empty(t, r.History(), "Expected no runs")
+}
+
+func TestRunnerHistorySize(t *testing.T) {
+	r := &Runner{HistorySize: 2}
+	for attempt := 1; attempt <= 3; attempt++ {
+		r.record(JobRun{Attempt: attempt})
+	}
+	history := r.History()
+	assert.Len(t, history, 2, "Expected the history to be capped")
+	assert.Equal(t, 2, history[0].Attempt, "Expected the oldest runs to be dropped")
+	assert.Equal(t, 3, history[1].Attempt, "Expected the most recent run last")
+}
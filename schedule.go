@@ -0,0 +1,340 @@
+package casoncelli
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	nsPerDay  = int64(24 * time.Hour)
+	nsPerWeek = 7 * nsPerDay
+)
+
+// Schedule is the compiled form of a Casoncelli.
+//
+// The edges of the periods are parsed once and flattened into sorted
+// interval tables, so that Contains runs in logarithmic time without
+// allocating. A Schedule is immutable and safe for concurrent use.
+type Schedule struct {
+	source  Casoncelli
+	periods []Period
+	always  []int32
+	custom  []int32
+	// recurring are the members of the weekly timeline
+	recurring []int32
+	weekly    timeline
+	absolute  timeline
+	// layered is set when some periods exclude times, so that the members
+	// covering a time must be weighed by priority
+	layered bool
+}
+
+// Compile validates the Casoncelli and returns its compiled Schedule.
+// Later changes to the Casoncelli are not reflected in the Schedule.
+func (c *Casoncelli) Compile() (*Schedule, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	s := &Schedule{
+		source:  Casoncelli{Periods: slices.Clone(c.Periods), Boundary: c.Boundary, Overrides: slices.Clone(c.Overrides)},
+		periods: make([]Period, len(c.Periods)),
+	}
+	var weekly, absolute []interval
+	for i, period := range c.Periods {
+		period = c.resolve(period)
+		s.periods[i] = period
+		member := int32(i)
+		if labelOf(period).Effect == Exclude {
+			s.layered = true
+		}
+
+		switch p := period.(type) {
+		case AlwaysPeriod:
+			s.always = append(s.always, member)
+		case NeverPeriod:
+		case DailyPeriod:
+			if p.limited() {
+				// the weekly timeline cannot tell the validity apart
+				s.custom = append(s.custom, member)
+				break
+			}
+			s.recurring = append(s.recurring, member)
+			from, _ := parseHour(p.From.Hour)
+			to, _ := parseHour(p.To.Hour)
+			for day := range int64(7) {
+				weekly = append(weekly, recurring(day*nsPerDay+from, day*nsPerDay+to, nsPerDay, p.Boundary, member))
+			}
+		case WeeklyPeriod:
+			if p.limited() {
+				s.custom = append(s.custom, member)
+				break
+			}
+			s.recurring = append(s.recurring, member)
+			from, _ := parseHour(p.From.Hour)
+			to, _ := parseHour(p.To.Hour)
+			weekly = append(weekly, recurring(int64(p.From.Day)*nsPerDay+from, int64(p.To.Day)*nsPerDay+to, nsPerWeek, p.Boundary, member))
+		case OncePeriod:
+			absolute = append(absolute, interval{
+				start:   p.From.Timestamp.UnixNano(),
+				end:     p.To.Timestamp.UnixNano(),
+				startIn: p.Boundary.includesStart(),
+				endIn:   p.Boundary.includesEnd(),
+				member:  member,
+			})
+		default:
+			s.custom = append(s.custom, member)
+		}
+	}
+
+	s.weekly = newTimeline(wrapWeek(weekly), true)
+	s.absolute = newTimeline(absolute, false)
+	return s, nil
+}
+
+// Contains reports whether t is included in at least one of the periods,
+// and not excluded by a period of the same or a higher priority, unless an
+// override is in effect at t.
+func (s *Schedule) Contains(t time.Time) bool {
+	if o, ok := s.OverrideAt(t); ok {
+		return o.Active
+	}
+	if s.layered {
+		return s.including(t, s.weeklyAt(t), s.absolute.lookup(t.UnixNano())) != math.MaxInt32
+	}
+	if len(s.always) > 0 {
+		return true
+	}
+	if s.nearTransition(t) {
+		for _, i := range s.recurring {
+			if s.periods[i].Contains(t) {
+				return true
+			}
+		}
+	} else if len(s.weekly.lookup(weekOffset(t))) > 0 {
+		return true
+	}
+	if len(s.absolute.lookup(t.UnixNano())) > 0 {
+		return true
+	}
+	for _, i := range s.custom {
+		if s.periods[i].Contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsNow reports whether the current moment is included in at least one of the periods.
+func (s *Schedule) ContainsNow() bool {
+	return s.Contains(time.Now())
+}
+
+// Casoncelli returns a copy of the Casoncelli the schedule was compiled from.
+func (s *Schedule) Casoncelli() Casoncelli {
+	c := s.source
+	c.Periods = slices.Clone(c.Periods)
+	c.Overrides = slices.Clone(c.Overrides)
+	return c
+}
+
+// transitionMargin is how close to a change of the UTC offset of its
+// location a time must be to share its day: a day lasts at most 25 hours.
+const transitionMargin = 26 * time.Hour
+
+// nearTransition reports whether the UTC offset of the location of t changes
+// within transitionMargin of t, like when daylight saving time starts or
+// ends. On those days the wall clock skips or repeats some times, and the
+// recurring periods place their edges where time.Date does, which the weekly
+// timeline cannot tell.
+func (s *Schedule) nearTransition(t time.Time) bool {
+	if len(s.recurring) == 0 {
+		return false
+	}
+	_, before := t.Add(-transitionMargin).Zone()
+	_, after := t.Add(transitionMargin).Zone()
+	return before != after
+}
+
+// weeklyAt returns the members of the weekly timeline containing t, asking
+// the periods themselves near a change of the UTC offset.
+func (s *Schedule) weeklyAt(t time.Time) []int32 {
+	if !s.nearTransition(t) {
+		return s.weekly.lookup(weekOffset(t))
+	}
+	var members []int32
+	for _, i := range s.recurring {
+		if s.periods[i].Contains(t) {
+			members = append(members, i)
+		}
+	}
+	return members
+}
+
+// weekOffset returns the nanoseconds elapsed since the start of the week
+// (sunday at midnight) on the wall clock of t.
+func weekOffset(t time.Time) int64 {
+	hour, min, sec := t.Clock()
+	return int64(t.Weekday())*nsPerDay +
+		int64(hour)*int64(time.Hour) +
+		int64(min)*int64(time.Minute) +
+		int64(sec)*int64(time.Second) +
+		int64(t.Nanosecond())
+}
+
+// parseHour returns the offset from midnight of an "HH:MM" hour. The hour
+// must be zero-padded, since the periods compare hours as strings.
+func parseHour(hour string) (int64, error) {
+	tokens := strings.Split(hour, ":")
+	if len(hour) != 5 || len(tokens) != 2 || strings.Trim(tokens[0]+tokens[1], "0123456789") != "" {
+		return 0, fmt.Errorf("invalid hour format: %s", hour)
+	}
+
+	h, err := strconv.Atoi(tokens[0])
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid hour value: %s", tokens[0])
+	}
+
+	m, err := strconv.Atoi(tokens[1])
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid minute value: %s", tokens[1])
+	}
+
+	return int64(h)*int64(time.Hour) + int64(m)*int64(time.Minute), nil
+}
+
+// interval is a span of a timeline covered by the period at index member.
+type interval struct {
+	start, end     int64
+	startIn, endIn bool
+	member         int32
+}
+
+func (iv interval) covers(x int64) bool {
+	return (iv.start < x || (iv.startIn && iv.start == x)) && (x < iv.end || (iv.endIn && x == iv.end))
+}
+
+// recurring returns the interval between two edges of a period repeating
+// every cycle; an end preceding the start belongs to the next cycle.
+func recurring(start, end, cycle int64, b Boundary, member int32) interval {
+	if end < start || (end == start && !b.isClosed()) {
+		end += cycle
+	}
+	return interval{start: start, end: end, startIn: b.includesStart(), endIn: b.includesEnd(), member: member}
+}
+
+// wrapWeek splits the intervals crossing the end of the week, so that all
+// of them lie within [0, nsPerWeek].
+func wrapWeek(intervals []interval) []interval {
+	result := make([]interval, 0, len(intervals))
+	for _, iv := range intervals {
+		if iv.start >= nsPerWeek {
+			iv.start -= nsPerWeek
+			iv.end -= nsPerWeek
+		}
+		if iv.end <= nsPerWeek {
+			result = append(result, iv)
+			continue
+		}
+		head, tail := iv, iv
+		head.end, head.endIn = nsPerWeek, false
+		tail.start, tail.startIn = 0, true
+		tail.end -= nsPerWeek
+		result = append(result, head, tail)
+	}
+	return result
+}
+
+// timeline partitions an axis in the edges of a set of intervals and the
+// open gaps between them, recording the members covering each piece.
+type timeline struct {
+	points []int64
+	at     [][]int32
+	after  [][]int32
+}
+
+// newTimeline builds the timeline of the intervals. A cyclic timeline spans
+// a single week, starting at 0; otherwise the axis is unbounded and nothing
+// lies before the first or after the last edge.
+func newTimeline(intervals []interval, cyclic bool) timeline {
+	var points []int64
+	if cyclic {
+		points = append(points, 0)
+	}
+	for _, iv := range intervals {
+		points = append(points, iv.start, iv.end)
+	}
+	slices.Sort(points)
+	points = slices.Compact(points)
+	// the end of the week is the start of the next one: the intervals
+	// including it as their end cover point 0
+	if cyclic && len(points) > 0 && points[len(points)-1] == nsPerWeek {
+		points = points[:len(points)-1]
+	}
+
+	tl := timeline{
+		points: points,
+		at:     make([][]int32, len(points)),
+		after:  make([][]int32, len(points)),
+	}
+	var members []int32
+	add := func(cover func(interval) bool) []int32 {
+		from := len(members)
+		for _, iv := range intervals {
+			if cover(iv) && !slices.Contains(members[from:], iv.member) {
+				members = append(members, iv.member)
+			}
+		}
+		slices.Sort(members[from:])
+		return members[from:len(members):len(members)]
+	}
+	for i, x := range points {
+		next := nsPerWeek
+		if i+1 < len(points) {
+			next = points[i+1]
+		} else if !cyclic {
+			next = x
+		}
+		tl.at[i] = add(func(iv interval) bool { return iv.covers(x) || (cyclic && x == 0 && iv.covers(nsPerWeek)) })
+		tl.after[i] = add(func(iv interval) bool { return next > x && iv.start <= x && iv.end >= next })
+	}
+	return tl
+}
+
+// lookup returns the members covering x.
+func (tl timeline) lookup(x int64) []int32 {
+	i, found := slices.BinarySearch(tl.points, x)
+	switch {
+	case found:
+		return tl.at[i]
+	case i == 0:
+		return nil
+	default:
+		return tl.after[i-1]
+	}
+}
+
+// locate returns the members covering x, along with the open range (lo, hi)
+// around x where they do not change. When x is an edge, lo and hi equal x.
+// The range of a cyclic timeline does not extend past the end of the week.
+func (tl timeline) locate(x int64, cyclic bool) (members []int32, lo, hi int64) {
+	i, found := slices.BinarySearch(tl.points, x)
+	if found {
+		return tl.at[i], x, x
+	}
+
+	hi = math.MaxInt64
+	if i < len(tl.points) {
+		hi = tl.points[i]
+	} else if cyclic {
+		hi = nsPerWeek
+	}
+	if i == 0 {
+		return nil, math.MinInt64, hi
+	}
+	return tl.after[i-1], tl.points[i-1], hi
+}
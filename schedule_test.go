@@ -0,0 +1,244 @@
+package casoncelli
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scheduleInstants() []time.Time {
+	layout := "2006-01-02 15:04:05"
+	start, _ := time.Parse(layout, "2025-04-26 00:00:00")
+	var instants []time.Time
+	for ts := start; ts.Before(start.AddDate(0, 0, 8)); ts = ts.Add(30 * time.Minute) {
+		instants = append(instants, ts, ts.Add(-time.Nanosecond), ts.Add(time.Nanosecond))
+	}
+	for _, extra := range []string{"2025-04-29 07:35:00", "2025-05-01 22:22:00", "2025-04-29 11:30:00", "2025-04-29 15:30:00"} {
+		ts, _ := time.Parse(layout, extra)
+		instants = append(instants, ts, ts.Add(-time.Nanosecond), ts.Add(time.Nanosecond))
+	}
+	return instants
+}
+
+func TestScheduleContainsMatchesPeriods(t *testing.T) {
+	start := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		NeverPeriod{},
+		OncePeriod{From: TimestampEdge{Timestamp: start}, To: TimestampEdge{Timestamp: start.Add(2 * time.Hour)}},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(time.Hour)}, To: TimestampEdge{Timestamp: start.Add(3 * time.Hour)}, Boundary: Open},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(-time.Hour)}, To: TimestampEdge{Timestamp: start.Add(-time.Hour)}},
+	}}
+	for _, b := range []Boundary{"", Closed, HalfOpen, Open} {
+		c.Periods = append(c.Periods,
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "12:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Tuesday, Hour: "07:35"}, To: DayTimeEdge{Day: time.Thursday, Hour: "22:22"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Friday, Hour: "18:00"}, To: DayTimeEdge{Day: time.Friday, Hour: "10:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "00:00"}, Boundary: b},
+		)
+	}
+	instants := scheduleInstants()
+
+	for i, period := range c.Periods {
+		single := Casoncelli{Periods: []Period{period}}
+		schedule, err := single.Compile()
+		assert.NoError(t, err, "Expected period %d to compile", i)
+		for _, ts := range instants {
+			assert.Equal(t, period.Contains(ts), schedule.Contains(ts), "Expected period %d and schedule to agree at %s", i, ts.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+func TestScheduleContainsMatchesCasoncelli(t *testing.T) {
+	fixture := Casoncelli{
+		Boundary: HalfOpen,
+		Periods: []Period{
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}},
+			DailyPeriod{From: TimeEdge{Hour: "17:00"}, To: TimeEdge{Hour: "18:00"}, Boundary: Open},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}},
+		},
+	}
+	schedule, err := fixture.Compile()
+	assert.NoError(t, err, "Expected the fixture to compile")
+
+	for _, ts := range scheduleInstants() {
+		assert.Equal(t, fixture.Contains(ts), schedule.Contains(ts), "Expected casoncelli and schedule to agree at %s", ts.Format(time.RFC3339Nano))
+	}
+}
+
+func TestScheduleMatchesCasoncelliAtWeekEdges(t *testing.T) {
+	hours := []string{"00:00", "12:00", "22:00"}
+	boundaries := []Boundary{"", Closed, HalfOpen, Open, OpenClosed}
+	var instants []time.Time
+	for _, day := range []int{3, 4, 5, 10, 11} {
+		for _, hour := range []int{0, 12, 22} {
+			at := time.Date(2025, 5, day, hour, 0, 0, 0, time.UTC)
+			instants = append(instants, at.Add(-time.Nanosecond), at, at.Add(time.Nanosecond))
+		}
+	}
+
+	r := rand.New(rand.NewSource(42))
+	for range 500 {
+		var c Casoncelli
+		for range 1 + r.Intn(3) {
+			label := PeriodLabel{Priority: r.Intn(2)}
+			if r.Intn(3) == 0 {
+				label.Effect = Exclude
+			}
+			boundary := boundaries[r.Intn(len(boundaries))]
+			if r.Intn(2) == 0 {
+				c.Periods = append(c.Periods, DailyPeriod{PeriodLabel: label, From: TimeEdge{Hour: hours[r.Intn(len(hours))]}, To: TimeEdge{Hour: hours[r.Intn(len(hours))]}, Boundary: boundary})
+			} else {
+				from := DayTimeEdge{Day: time.Weekday((5 + r.Intn(3)) % 7), Hour: hours[r.Intn(len(hours))]}
+				to := DayTimeEdge{Day: time.Weekday((6 + r.Intn(3)) % 7), Hour: hours[r.Intn(len(hours))]}
+				c.Periods = append(c.Periods, WeeklyPeriod{PeriodLabel: label, From: from, To: to, Boundary: boundary})
+			}
+		}
+		schedule, err := c.Compile()
+		assert.NoError(t, err, "Expected the schedule to compile")
+
+		memberships := schedule.Evaluate(instants)
+		for i, at := range instants {
+			expected := c.Contains(at)
+			assert.Equal(t, expected, schedule.Contains(at), "Expected the schedule of %v to contain %s: %v", c.Periods, at.Format(time.RFC3339Nano), expected)
+			assert.Equal(t, expected, memberships[i].Contained, "Expected the membership of %v at %s to match Contains", c.Periods, at.Format(time.RFC3339Nano))
+		}
+	}
+}
+
+func TestScheduleAlwaysAndCustom(t *testing.T) {
+	c := Casoncelli{Periods: []Period{AlwaysPeriod{}}}
+	schedule, err := c.Compile()
+	assert.NoError(t, err, "Expected no error compiling an always period")
+	assert.True(t, schedule.ContainsNow(), "Expected always schedule to contain now")
+
+	c = Casoncelli{Periods: []Period{MockPeriod{result: true}}}
+	schedule, err = c.Compile()
+	assert.NoError(t, err, "Expected no error compiling a custom period")
+	assert.True(t, schedule.ContainsNow(), "Expected custom period to be evaluated")
+
+	c = Casoncelli{}
+	schedule, err = c.Compile()
+	assert.NoError(t, err, "Expected no error compiling an empty casoncelli")
+	assert.False(t, schedule.ContainsNow(), "Expected empty schedule to not contain now")
+}
+
+func TestScheduleIsImmutable(t *testing.T) {
+	c := Casoncelli{Periods: []Period{AlwaysPeriod{}}}
+	schedule, _ := c.Compile()
+	c.Periods[0] = NeverPeriod{}
+	assert.True(t, schedule.ContainsNow(), "Expected schedule to ignore later changes to the casoncelli")
+}
+
+func TestCompileValidation(t *testing.T) {
+	invalid := []Period{
+		DailyPeriod{From: TimeEdge{Hour: "9"}, To: TimeEdge{Hour: "17:00"}},
+		DailyPeriod{From: TimeEdge{Hour: "9:00"}, To: TimeEdge{Hour: "17:00"}},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Monday, Hour: "09:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "10:5"}},
+		DailyPeriod{From: TimeEdge{Hour: "+9:00"}, To: TimeEdge{Hour: "17:00"}},
+		DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "25:00"}},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Monday, Hour: "09:00"}, To: DayTimeEdge{Day: time.Weekday(9), Hour: "10:00"}},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Monday, Hour: "09:60"}, To: DayTimeEdge{Day: time.Monday, Hour: "10:00"}},
+		OncePeriod{From: TimestampEdge{Timestamp: time.Now()}, To: TimestampEdge{Timestamp: time.Now().Add(-time.Hour)}},
+		DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:00"}, Boundary: "ajar"},
+		nil,
+	}
+	for i, period := range invalid {
+		c := Casoncelli{Periods: []Period{period}}
+		_, err := c.Compile()
+		assert.Error(t, err, "Expected invalid period %d to be rejected", i)
+	}
+
+	c := Casoncelli{Boundary: "ajar"}
+	assert.Error(t, c.Validate(), "Expected invalid default boundary to be rejected")
+}
+
+func TestScheduleContainsDaylightSavingTime(t *testing.T) {
+	rome, err := time.LoadLocation("Europe/Rome")
+	assert.NoError(t, err, "Expected the location to be loaded")
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{From: TimeEdge{Hour: "02:45"}, To: TimeEdge{Hour: "11:00"}},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Sunday, Hour: "02:30"}, To: DayTimeEdge{Day: time.Sunday, Hour: "05:00"}, Boundary: HalfOpen},
+	}}
+	schedule, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	// 02:45 does not exist on the day daylight saving time starts
+	gap := time.Date(2025, 3, 30, 3, 0, 0, 0, rome)
+	assert.False(t, c.Contains(gap), "Expected the periods to start after the gap")
+	assert.False(t, schedule.Contains(gap), "Expected the schedule to match the periods in the gap")
+
+	var ts []time.Time
+	for _, day := range []time.Time{time.Date(2025, 3, 29, 0, 0, 0, 0, rome), time.Date(2025, 10, 25, 0, 0, 0, 0, rome)} {
+		for at := day; at.Before(day.Add(72 * time.Hour)); at = at.Add(15 * time.Minute) {
+			ts = append(ts, at.Add(-time.Nanosecond), at, at.Add(time.Nanosecond))
+		}
+	}
+	for i, m := range schedule.Evaluate(ts) {
+		expected := c.Contains(ts[i])
+		assert.Equal(t, expected, schedule.Contains(ts[i]), "Expected the schedule to contain %s: %v", ts[i], expected)
+		assert.Equal(t, expected, m.Contained, "Expected the membership at %s to match Contains", ts[i])
+		assert.Equal(t, expected, len(schedule.Active(ts[i])) > 0, "Expected the active occurrences at %s to match Contains", ts[i])
+	}
+}
+
+func TestScheduleContainsDoesNotAllocate(t *testing.T) {
+	start := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		NeverPeriod{},
+		OncePeriod{From: TimestampEdge{Timestamp: start}, To: TimestampEdge{Timestamp: start.Add(2 * time.Hour)}, Boundary: Open},
+		DailyPeriod{From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: HalfOpen},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}},
+	}}
+	schedule, _ := c.Compile()
+	ts := time.Now()
+	allocs := testing.AllocsPerRun(100, func() {
+		schedule.Contains(ts)
+	})
+	assert.Zero(t, allocs, "Expected Contains to not allocate")
+}
+
+// benchmarkCasoncelli has no period matching most timestamps, so that the
+// whole list is scanned.
+func benchmarkCasoncelli() Casoncelli {
+	start := time.Date(2025, 4, 29, 12, 30, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		NeverPeriod{},
+		OncePeriod{From: TimestampEdge{Timestamp: start}, To: TimestampEdge{Timestamp: start.Add(2 * time.Hour)}},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(time.Hour)}, To: TimestampEdge{Timestamp: start.Add(3 * time.Hour)}, Boundary: Open},
+		OncePeriod{From: TimestampEdge{Timestamp: start.Add(-time.Hour)}, To: TimestampEdge{Timestamp: start.Add(-time.Hour)}},
+	}}
+	for _, b := range []Boundary{"", Closed, HalfOpen, Open} {
+		c.Periods = append(c.Periods,
+			DailyPeriod{From: TimeEdge{Hour: "09:00"}, To: TimeEdge{Hour: "17:30"}, Boundary: b},
+			DailyPeriod{From: TimeEdge{Hour: "22:00"}, To: TimeEdge{Hour: "06:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Tuesday, Hour: "07:35"}, To: DayTimeEdge{Day: time.Thursday, Hour: "22:22"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Saturday, Hour: "23:00"}, To: DayTimeEdge{Day: time.Sunday, Hour: "07:00"}, Boundary: b},
+			WeeklyPeriod{From: DayTimeEdge{Day: time.Friday, Hour: "18:00"}, To: DayTimeEdge{Day: time.Friday, Hour: "10:00"}, Boundary: b},
+		)
+	}
+	return c
+}
+
+func BenchmarkCasoncelliContains(b *testing.B) {
+	c := benchmarkCasoncelli()
+	ts := time.Date(2025, 4, 30, 8, 15, 0, 0, time.UTC)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.Contains(ts)
+	}
+}
+
+func BenchmarkScheduleContains(b *testing.B) {
+	c := benchmarkCasoncelli()
+	schedule, _ := c.Compile()
+	ts := time.Date(2025, 4, 30, 8, 15, 0, 0, time.UTC)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		schedule.Contains(ts)
+	}
+}
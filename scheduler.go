@@ -0,0 +1,230 @@
+package casoncelli
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMisfireThreshold is how late a firing can be before it is considered
+// a misfire, for a Scheduler without a MisfireThreshold.
+const DefaultMisfireThreshold = time.Minute
+
+// MisfirePolicy tells what a Scheduler does with the firings it missed, like
+// while the process was down.
+type MisfirePolicy int
+
+const (
+	// MisfireSkip drops the missed firings.
+	MisfireSkip MisfirePolicy = iota
+	// MisfireRunOnce runs only the most recent of the missed firings.
+	MisfireRunOnce
+	// MisfireCatchUp runs all the missed firings.
+	MisfireCatchUp
+)
+
+func (p MisfirePolicy) String() string {
+	switch p {
+	case MisfireSkip:
+		return "skip"
+	case MisfireRunOnce:
+		return "run once"
+	case MisfireCatchUp:
+		return "catch up"
+	default:
+		return fmt.Sprintf("MisfirePolicy(%d)", int(p))
+	}
+}
+
+// Trigger is a callback run by a Scheduler at the start, or at the end, of
+// the occurrences of the periods.
+type Trigger struct {
+	// Name, if set, restricts the trigger to the periods with this name.
+	Name string
+	// Kind is Enter to fire at the start of the occurrences, Exit to fire
+	// at their end.
+	Kind EventKind
+	// Func is the callback. Its context is canceled when the scheduler stops.
+	Func func(ctx context.Context, f Firing)
+	// Misfire is the policy for the missed firings.
+	Misfire MisfirePolicy
+	// Jitter delays every firing by a random duration up to Jitter.
+	Jitter time.Duration
+	// MaxConcurrent is the number of runs of the trigger allowed at the same
+	// time, no limit if zero.
+	MaxConcurrent int
+}
+
+// Firing is a run of a Trigger.
+type Firing struct {
+	Kind       EventKind
+	Occurrence Occurrence
+	// Scheduled is the edge of the occurrence the firing is for.
+	Scheduled time.Time
+	// Misfired reports whether the firing was missed, and is run late.
+	Misfired bool
+}
+
+// Scheduler runs the Triggers at the edges of the occurrences of the
+// periods of a Live schedule, like a cron whose schedule is a Casoncelli.
+//
+// The scheduler sleeps until the next edge, but never longer than Interval.
+// The edges it wakes up too late for, by more than MisfireThreshold, are
+// misfires, handled by the Misfire policy of each trigger; the edges since
+// Since are misfires too, which lets a restarted process catch up with the
+// firings missed while it was down.
+//
+// Like for a Watcher, the edges follow the overrides: an inactive override
+// moves the edges of the occurrences it cuts to its own, and an active
+// override fires like an occurrence with Index -1.
+//
+// Only the periods defined by this package are scheduled.
+type Scheduler struct {
+	// Live is the schedule.
+	Live *Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+	// Interval is the longest the scheduler sleeps, DefaultWatchInterval if zero.
+	Interval time.Duration
+	// Triggers are the callbacks to run.
+	Triggers []Trigger
+	// Since, if set, is the time the scheduler last ran: the edges between
+	// Since and the start of Run are misfires.
+	Since time.Time
+	// MisfireThreshold is how late a firing can be before it is a misfire,
+	// DefaultMisfireThreshold if zero.
+	MisfireThreshold time.Duration
+	// MaxConcurrent is the number of callbacks allowed to run at the same
+	// time, no limit if zero.
+	MaxConcurrent int
+}
+
+// Run schedules the triggers until ctx is done, then waits for the running
+// callbacks and returns the context error.
+func (s *Scheduler) Run(ctx context.Context) error {
+	clock := clockOrSystem(s.Clock)
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	threshold := s.MisfireThreshold
+	if threshold <= 0 {
+		threshold = DefaultMisfireThreshold
+	}
+
+	global := semaphore(s.MaxConcurrent)
+	limits := make([]chan struct{}, len(s.Triggers))
+	for i, t := range s.Triggers {
+		limits[i] = semaphore(t.MaxConcurrent)
+	}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	last := s.Since
+	for {
+		changed := s.Live.Changed()
+		schedule := s.Live.Schedule()
+		now := clock.Now()
+		// the edges up to last already fired, unless the wall clock moved
+		// back too far to be trusted
+		if last.IsZero() || last.Sub(now) > recurringLookaround {
+			last = now
+		}
+
+		if now.After(last) {
+			for i, t := range s.Triggers {
+				for _, f := range firings(schedule, t, last, now, threshold) {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						fire(ctx, clock, t, f, limits[i], global)
+					}()
+				}
+			}
+			last = now
+		}
+
+		wait := interval
+		if edge, ok := schedule.nextEdge(now); ok && edge.Sub(now) < wait {
+			wait = edge.Sub(now)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-clock.After(wait):
+		}
+	}
+}
+
+// firings returns the firings of t for the edges in (last, now], applying
+// its misfire policy.
+func firings(s *Schedule, t Trigger, last, now time.Time, threshold time.Duration) []Firing {
+	var result, missed []Firing
+	for _, occ := range s.withOverrides(s.Occurrences(last, now), last, now) {
+		if t.Name != "" && t.Name != occ.Label.Name {
+			continue
+		}
+		for _, edge := range s.transitionsOf(occ, t.Kind, last, now) {
+			f := Firing{Kind: t.Kind, Occurrence: occ, Scheduled: edge, Misfired: now.Sub(edge) > threshold}
+			if f.Misfired {
+				missed = append(missed, f)
+			} else {
+				result = append(result, f)
+			}
+		}
+	}
+
+	switch {
+	case t.Misfire == MisfireSkip:
+		missed = nil
+	case len(missed) > 0 && t.Misfire == MisfireRunOnce:
+		latest := missed[0]
+		for _, f := range missed[1:] {
+			if f.Scheduled.After(latest.Scheduled) {
+				latest = f
+			}
+		}
+		missed = []Firing{latest}
+	}
+	result = append(missed, result...)
+	// the exits of the occurrences are not sorted like their starts
+	slices.SortStableFunc(result, func(a, b Firing) int {
+		return a.Scheduled.Compare(b.Scheduled)
+	})
+	return result
+}
+
+// fire runs the callback of t after its jitter, once a slot is available.
+func fire(ctx context.Context, clock Clock, t Trigger, f Firing, slots ...chan struct{}) {
+	if t.Jitter > 0 {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.After(rand.N(t.Jitter)):
+		}
+	}
+	for _, slot := range slots {
+		if slot == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case slot <- struct{}{}:
+			defer func() { <-slot }()
+		}
+	}
+	t.Func(ctx, f)
+}
+
+// semaphore returns a channel allowing n holders, nil if n is not positive.
+func semaphore(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
@@ -0,0 +1,204 @@
+package casoncelli
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// schedulerTest runs a Scheduler, of backupSchedule unless it has a Live,
+// against a fake clock, recording the firings.
+type schedulerTest struct {
+	clock  *fakeClock
+	cancel context.CancelFunc
+	done   chan error
+
+	mu      sync.Mutex
+	firings []Firing
+}
+
+func startScheduler(t *testing.T, now time.Time, s Scheduler) *schedulerTest {
+	live, err := NewLive(backupSchedule())
+	assert.NoError(t, err, "Expected the schedule to compile")
+	st := &schedulerTest{clock: newFakeClock(now), done: make(chan error)}
+	if s.Live == nil {
+		s.Live = live
+	}
+	s.Clock = st.clock
+	for i := range s.Triggers {
+		if s.Triggers[i].Func == nil {
+			s.Triggers[i].Func = st.record
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	st.cancel = cancel
+	go func() {
+		st.done <- s.Run(ctx)
+	}()
+	t.Cleanup(func() { st.stop() })
+	return st
+}
+
+func (st *schedulerTest) record(ctx context.Context, f Firing) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.firings = append(st.firings, f)
+}
+
+// stop stops the scheduler, returning the recorded firings.
+func (st *schedulerTest) stop() []Firing {
+	if st.cancel != nil {
+		st.cancel()
+		<-st.done
+		st.cancel = nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.firings
+}
+
+func (st *schedulerTest) recorded() int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return len(st.firings)
+}
+
+func TestSchedulerFires(t *testing.T) {
+	st := startScheduler(t, time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), Scheduler{
+		Triggers: []Trigger{{Kind: Enter}, {Kind: Exit, Name: "backup"}, {Kind: Enter, Name: "other"}},
+	})
+
+	st.clock.waitCalls(t, 1)
+	st.clock.Advance(time.Hour)
+	st.clock.waitCalls(t, 2)
+	st.clock.Advance(time.Hour)
+	st.clock.waitCalls(t, 3)
+
+	firings := st.stop()
+	assert.Len(t, firings, 2, "Expected the start and the end of the backup")
+	assert.Equal(t, Enter, firings[0].Kind, "Expected the start first")
+	assert.Equal(t, time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), firings[0].Scheduled, "Expected the start of the occurrence")
+	assert.Equal(t, Exit, firings[1].Kind, "Expected the end last")
+	assert.Equal(t, time.Date(2025, 5, 5, 11, 0, 0, 0, time.UTC), firings[1].Scheduled, "Expected the end of the occurrence")
+	assert.Equal(t, "backup", firings[1].Occurrence.Label.Name, "Expected the fired occurrence")
+	assert.False(t, firings[0].Misfired, "Expected the firing on time")
+}
+
+func TestSchedulerOverrides(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	c := backupSchedule()
+	c.Overrides = []Override{
+		{From: now.Add(30 * time.Minute), Until: now.Add(90 * time.Minute)},
+		{ID: "incident", Active: true, From: now.Add(3 * time.Hour), Until: now.Add(4 * time.Hour)},
+	}
+	live, err := NewLive(c)
+	assert.NoError(t, err, "Expected the schedule to compile")
+	st := startScheduler(t, now, Scheduler{Live: live, Triggers: []Trigger{{Kind: Enter}, {Kind: Exit}}})
+
+	st.clock.waitCalls(t, 1)
+	for calls, step := range []time.Duration{30 * time.Minute, 30 * time.Minute, 30 * time.Minute, 30 * time.Minute, time.Hour, time.Hour} {
+		st.clock.Advance(step)
+		st.clock.waitCalls(t, calls+2)
+	}
+
+	firings := st.stop()
+	slices.SortFunc(firings, func(a, b Firing) int { return a.Scheduled.Compare(b.Scheduled) })
+	var scheduled []time.Time
+	var indexes []int
+	for _, f := range firings {
+		scheduled = append(scheduled, f.Scheduled)
+		indexes = append(indexes, f.Occurrence.Index)
+	}
+	assert.Equal(t, []time.Time{now.Add(90 * time.Minute), now.Add(2 * time.Hour), now.Add(3 * time.Hour), now.Add(4 * time.Hour)}, scheduled, "Expected the firings to follow the overrides")
+	assert.Equal(t, []int{0, 0, -1, -1}, indexes, "Expected the active override to fire on its own")
+}
+
+func TestSchedulerMisfire(t *testing.T) {
+	since := time.Date(2025, 5, 2, 12, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC)
+	for policy, expected := range map[MisfirePolicy][]time.Time{
+		MisfireSkip:    nil,
+		MisfireRunOnce: {time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC)},
+		MisfireCatchUp: {
+			time.Date(2025, 5, 3, 10, 0, 0, 0, time.UTC),
+			time.Date(2025, 5, 4, 10, 0, 0, 0, time.UTC),
+			time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC),
+		},
+	} {
+		st := startScheduler(t, now, Scheduler{
+			Since:         since,
+			Triggers:      []Trigger{{Kind: Enter, Misfire: policy}},
+			MaxConcurrent: 1,
+		})
+		st.clock.waitCalls(t, 1)
+		assert.Eventually(t, func() bool { return st.recorded() >= len(expected) }, time.Second, time.Millisecond, "Expected the missed firings to run")
+
+		var scheduled []time.Time
+		for _, f := range st.stop() {
+			assert.True(t, f.Misfired, "Expected a misfire")
+			scheduled = append(scheduled, f.Scheduled)
+		}
+		assert.ElementsMatch(t, expected, scheduled, "Expected the misfires to be handled by the %s policy", policy)
+	}
+}
+
+func TestSchedulerWallClockJump(t *testing.T) {
+	st := startScheduler(t, time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), Scheduler{
+		Triggers: []Trigger{{Kind: Enter, Misfire: MisfireSkip}, {Kind: Exit, Misfire: MisfireRunOnce}},
+	})
+
+	st.clock.waitCalls(t, 1)
+	st.clock.Jump(3 * time.Hour)
+	st.clock.Advance(time.Hour)
+	st.clock.waitCalls(t, 2)
+
+	firings := st.stop()
+	assert.Len(t, firings, 1, "Expected only the missed exit to run")
+	assert.Equal(t, Exit, firings[0].Kind, "Expected the missed exit")
+	assert.True(t, firings[0].Misfired, "Expected a misfire")
+}
+
+func TestSchedulerConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	st := startScheduler(t, time.Date(2025, 5, 5, 12, 0, 0, 0, time.UTC), Scheduler{
+		Since: time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC),
+		Triggers: []Trigger{{Kind: Enter, Misfire: MisfireCatchUp, MaxConcurrent: 2, Func: func(ctx context.Context, f Firing) {
+			n := running.Add(1)
+			for {
+				if p := peak.Load(); n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+		}}},
+	})
+
+	st.clock.waitCalls(t, 1)
+	assert.Eventually(t, func() bool { return running.Load() == 2 }, time.Second, time.Millisecond, "Expected two runs at the same time")
+	close(release)
+	st.stop()
+	assert.Equal(t, int32(2), peak.Load(), "Expected at most two runs at the same time")
+}
+
+func TestSchedulerJitter(t *testing.T) {
+	st := startScheduler(t, time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC), Scheduler{
+		Triggers: []Trigger{{Kind: Enter, Jitter: 10 * time.Minute}},
+	})
+
+	st.clock.waitCalls(t, 1)
+	st.clock.Advance(time.Hour)
+	// the scheduler and the jitter of the firing
+	st.clock.waitCalls(t, 3)
+	st.clock.Advance(10 * time.Minute)
+	assert.Eventually(t, func() bool { return st.recorded() == 1 }, time.Second, time.Millisecond, "Expected the firing after the jitter")
+	firings := st.stop()
+	assert.Equal(t, time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), firings[0].Scheduled, "Expected the scheduled edge regardless of the jitter")
+}
@@ -0,0 +1,297 @@
+package casoncelli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServerMaxRange is the longest range of /occurrences served by a
+// Server without a MaxRange.
+const DefaultServerMaxRange = 366 * 24 * time.Hour
+
+// serverMaxNext is the most occurrences served by /next.
+const serverMaxNext = 1000
+
+// ServerOccurrence is an occurrence in the responses of a Server.
+type ServerOccurrence struct {
+	ID          string `json:"id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Period is the index of the period, nil for the merged occurrences.
+	Period *int `json:"period,omitempty"`
+	// Start and End are nil for the occurrences without edges.
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+	Boundary Boundary   `json:"boundary,omitempty"`
+}
+
+// ServerStatus is the response of /status.
+type ServerStatus struct {
+	Time    time.Time          `json:"time"`
+	Active  bool               `json:"active"`
+	Periods []ServerOccurrence `json:"periods"`
+	// End is the end of the current merged occurrence, nil if unknown.
+	End *time.Time `json:"end,omitempty"`
+	// NextStart is the start of the next merged occurrence, when inactive.
+	NextStart *time.Time `json:"next_start,omitempty"`
+	// Override is the override deciding Active, if any.
+	Override *Override `json:"override,omitempty"`
+	// Version is the version of the schedule.
+	Version uint64 `json:"version"`
+}
+
+// ServerOccurrences is the response of /next and /occurrences.
+type ServerOccurrences struct {
+	Occurrences []ServerOccurrence `json:"occurrences"`
+	Version     uint64             `json:"version"`
+}
+
+// Server is an http.Handler serving a Live schedule to the services not
+// written in Go:
+//
+//	GET /status[?at=]               whether the schedule is active, why, and until when
+//	GET /next[?at=&n=&merged=]      the next n occurrences, 1 by default
+//	GET /occurrences?from=&to=[&merged=]
+//	                                the occurrences between from and to
+//	GET /calendar.ics               an iCalendar feed of the schedule
+//
+// Times are in RFC 3339, at is now by default, and merged=true merges the
+// overlapping occurrences. Every response carries an ETag of its contents,
+// and is answered with 304 Not Modified when the ETag matches If-None-Match:
+// the feed only changes with the schedule or the day, and the other responses
+// at fixed times with the schedule. A Server follows the replacements of its
+// Live, like the ones of a FileLoader.
+type Server struct {
+	// Live is the schedule served.
+	Live *Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+	// ICalendar exports /calendar.ics, starting from the current day; its
+	// Since and Clock are ignored.
+	ICalendar ICalendar
+	// MaxRange is the longest range of /occurrences, DefaultServerMaxRange if zero.
+	MaxRange time.Duration
+
+	mu   sync.Mutex
+	feed serverFeed
+}
+
+// serverFeed is the last iCalendar feed exported.
+type serverFeed struct {
+	version uint64
+	day     time.Time
+	body    []byte
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		serverError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+	schedule, version := s.Live.Load()
+	now := clockOrSystem(s.Clock).Now()
+
+	switch r.URL.Path {
+	case "/status":
+		at, err := serverTime(r, "at", now)
+		if err != nil {
+			serverError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		e := schedule.Explain(at)
+		st := ServerStatus{Time: at, Active: e.Contained, Periods: serverOccurrences(e.Occurrences), Override: e.Override, Version: version}
+		if end, ok := schedule.CurrentEnd(at); ok {
+			st.End = &end
+		}
+		if !st.Active {
+			if next := schedule.NextMerged(at, 1); len(next) > 0 && !next[0].Unbounded() {
+				st.NextStart = &next[0].Start
+			}
+		}
+		serveJSON(w, r, st)
+	case "/next":
+		at, err := serverTime(r, "at", now)
+		if err != nil {
+			serverError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		n := 1
+		if value := r.URL.Query().Get("n"); value != "" {
+			if n, err = strconv.Atoi(value); err != nil || n < 1 || n > serverMaxNext {
+				serverError(w, http.StatusBadRequest, "n must be between 1 and %d", serverMaxNext)
+				return
+			}
+		}
+		merged, err := serverBool(r, "merged")
+		if err != nil {
+			serverError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		next := schedule.Next
+		if merged {
+			next = schedule.NextMerged
+		}
+		serveJSON(w, r, ServerOccurrences{Occurrences: serverOccurrences(next(at, n)), Version: version})
+	case "/occurrences":
+		s.occurrences(w, r, schedule, version)
+	case "/calendar.ics":
+		body, err := s.calendar(schedule, version, now)
+		if err != nil {
+			serverError(w, http.StatusInternalServerError, "%v", err)
+			return
+		}
+		serve(w, r, "text/calendar; charset=utf-8", body)
+	default:
+		serverError(w, http.StatusNotFound, "%s not found", r.URL.Path)
+	}
+}
+
+func (s *Server) occurrences(w http.ResponseWriter, r *http.Request, schedule *Schedule, version uint64) {
+	if r.URL.Query().Get("from") == "" || r.URL.Query().Get("to") == "" {
+		serverError(w, http.StatusBadRequest, "from and to are required")
+		return
+	}
+	from, err := serverTime(r, "from", time.Time{})
+	if err != nil {
+		serverError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	to, err := serverTime(r, "to", time.Time{})
+	if err != nil {
+		serverError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	maxRange := s.MaxRange
+	if maxRange <= 0 {
+		maxRange = DefaultServerMaxRange
+	}
+	switch {
+	case to.Before(from):
+		serverError(w, http.StatusBadRequest, "to is before from")
+		return
+	case to.Sub(from) > maxRange:
+		serverError(w, http.StatusBadRequest, "the range is longer than %s", maxRange)
+		return
+	}
+	merged, err := serverBool(r, "merged")
+	if err != nil {
+		serverError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+
+	occurrences := schedule.Occurrences
+	if merged {
+		occurrences = schedule.Merged
+	}
+	serveJSON(w, r, ServerOccurrences{Occurrences: serverOccurrences(occurrences(from, to)), Version: version})
+}
+
+// calendar returns the iCalendar feed of the schedule, exported again only
+// when the schedule or the day change.
+func (s *Server) calendar(schedule *Schedule, version uint64, now time.Time) ([]byte, error) {
+	loc := s.ICalendar.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	year, month, day := now.In(loc).Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, loc)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.feed.body != nil && s.feed.version == version && s.feed.day.Equal(today) {
+		return s.feed.body, nil
+	}
+	ic := s.ICalendar
+	ic.Since, ic.Clock = today, s.Clock
+	var b bytes.Buffer
+	if err := ic.Export(&b, schedule.Casoncelli()); err != nil {
+		return nil, err
+	}
+	s.feed = serverFeed{version: version, day: today, body: b.Bytes()}
+	return s.feed.body, nil
+}
+
+func serverOccurrences(occurrences []Occurrence) []ServerOccurrence {
+	result := []ServerOccurrence{}
+	for _, occ := range occurrences {
+		o := ServerOccurrence{ID: occ.Label.ID, Name: occ.Label.Name, Description: occ.Label.Description, Boundary: occ.Boundary}
+		if occ.Index >= 0 {
+			o.Period = &occ.Index
+		}
+		if !occ.Unbounded() {
+			o.Start, o.End = &occ.Start, &occ.End
+		}
+		result = append(result, o)
+	}
+	return result
+}
+
+// serverTime returns the time in the query parameter name, or def if missing.
+func serverTime(r *http.Request, name string, def time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time", name)
+	}
+	return t, nil
+}
+
+// serverBool returns the boolean in the query parameter name, false if missing.
+func serverBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return b, nil
+}
+
+func serveJSON(w http.ResponseWriter, r *http.Request, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		serverError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	serve(w, r, "application/json", append(body, '\n'))
+}
+
+// serve writes body with an ETag, or answers 304 Not Modified when the
+// client already has it.
+func serve(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	for _, match := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		match = strings.TrimPrefix(strings.TrimSpace(match), "W/")
+		if match == etag || match == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.Write(body)
+}
+
+func serverError(w http.ResponseWriter, code int, format string, args ...any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,205 @@
+package casoncelli
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func serverGet(s *Server, target string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestServerStatus(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+
+	rec := serverGet(s, "/status")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the status")
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "Expected a JSON response")
+	var st ServerStatus
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st), "Expected a valid JSON body")
+	assert.True(t, st.Active, "Expected the schedule to be active")
+	assert.Equal(t, uint64(1), st.Version, "Expected the version of the schedule")
+	assert.Len(t, st.Periods, 1, "Expected the active period")
+	assert.Equal(t, "maintenance", st.Periods[0].Name, "Expected the name of the active period")
+	assert.Equal(t, "nightly", st.Periods[0].ID, "Expected the id of the active period")
+	assert.Equal(t, 0, *st.Periods[0].Period, "Expected the index of the active period")
+	assert.True(t, time.Date(2025, 5, 6, 3, 0, 0, 0, time.UTC).Equal(*st.End), "Expected the current end")
+
+	rec = serverGet(s, "/status?at=2025-05-06T12:00:00Z")
+	st = ServerStatus{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st), "Expected a valid JSON body")
+	assert.False(t, st.Active, "Expected the schedule to be inactive at noon")
+	assert.Empty(t, st.Periods, "Expected no active periods")
+	assert.True(t, time.Date(2025, 5, 7, 2, 0, 0, 0, time.UTC).Equal(*st.NextStart), "Expected the next start")
+
+	rec = serverGet(s, "/status?at=yesterday")
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "Expected an invalid time to be rejected")
+	assert.JSONEq(t, `{"error": "at must be an RFC 3339 time"}`, rec.Body.String(), "Expected the error")
+}
+
+func TestServerStatusOverride(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+	_, err := s.Live.Override(Override{ID: "release", From: time.Date(2025, 5, 6, 2, 0, 0, 0, time.UTC), Until: time.Date(2025, 5, 6, 4, 0, 0, 0, time.UTC), Reason: "release"})
+	assert.NoError(t, err, "Expected the override to be added")
+
+	var st ServerStatus
+	rec := serverGet(s, "/status")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &st), "Expected a valid JSON body")
+	assert.False(t, st.Active, "Expected the schedule to be forced inactive")
+	assert.Len(t, st.Periods, 1, "Expected the matching period anyway")
+	assert.Equal(t, "release", st.Override.ID, "Expected the override")
+	assert.True(t, time.Date(2025, 5, 7, 2, 0, 0, 0, time.UTC).Equal(*st.NextStart), "Expected the next start after the override")
+}
+
+func TestServerNext(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+
+	var next ServerOccurrences
+	rec := serverGet(s, "/next?at=2025-05-09T12:00:00Z&n=2")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &next), "Expected a valid JSON body")
+	assert.Len(t, next.Occurrences, 2, "Expected two occurrences")
+	assert.Equal(t, "freeze", next.Occurrences[0].Name, "Expected the freeze first")
+	assert.True(t, time.Date(2025, 5, 9, 16, 0, 0, 0, time.UTC).Equal(*next.Occurrences[0].Start), "Expected the start of the freeze")
+	assert.Equal(t, "maintenance", next.Occurrences[1].Name, "Expected the maintenance next")
+
+	next = ServerOccurrences{}
+	rec = serverGet(s, "/next?at=2025-05-09T12:00:00Z&merged=true")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &next), "Expected a valid JSON body")
+	assert.Len(t, next.Occurrences, 1, "Expected a single occurrence")
+	assert.Nil(t, next.Occurrences[0].Period, "Expected the merged occurrence to have no period")
+	assert.True(t, time.Date(2025, 5, 12, 8, 0, 0, 0, time.UTC).Equal(*next.Occurrences[0].End), "Expected the end of the weekend")
+
+	for _, target := range []string{"/next?n=0", "/next?n=1001", "/next?merged=maybe"} {
+		assert.Equal(t, http.StatusBadRequest, serverGet(s, target).Code, "Expected %s to be rejected", target)
+	}
+}
+
+func TestServerOccurrences(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+
+	var occurrences ServerOccurrences
+	rec := serverGet(s, "/occurrences?from=2025-05-06T00:00:00Z&to=2025-05-08T00:00:00Z")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the occurrences")
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &occurrences), "Expected a valid JSON body")
+	assert.Len(t, occurrences.Occurrences, 2, "Expected the maintenance of two days")
+	assert.Equal(t, "update indexes", occurrences.Occurrences[0].Description, "Expected the description")
+
+	for _, target := range []string{
+		"/occurrences",
+		"/occurrences?from=2025-05-06T00:00:00Z",
+		"/occurrences?from=2025-05-08T00:00:00Z&to=2025-05-06T00:00:00Z",
+		"/occurrences?from=2025-05-06T00:00:00Z&to=2027-05-06T00:00:00Z",
+	} {
+		assert.Equal(t, http.StatusBadRequest, serverGet(s, target).Code, "Expected %s to be rejected", target)
+	}
+}
+
+func TestServerETag(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+	target := "/occurrences?from=2025-05-06T00:00:00Z&to=2025-05-08T00:00:00Z"
+
+	rec := serverGet(s, target)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag, "Expected an ETag")
+	rec = serverGet(s, target, "If-None-Match", `"other", `+etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code, "Expected the response to be not modified")
+	assert.Empty(t, rec.Body.String(), "Expected no body")
+
+	// the schedule is reloaded
+	_, err := s.Live.Remove("freeze")
+	assert.NoError(t, err, "Expected the period to be removed")
+	rec = serverGet(s, target, "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the new schedule to be served")
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"), "Expected the ETag to change with the schedule")
+	assert.Contains(t, rec.Body.String(), `"version":2`, "Expected the new version")
+}
+
+func TestServerCalendar(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+
+	rec := serverGet(s, "/calendar.ics")
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected the feed")
+	assert.Equal(t, "text/calendar; charset=utf-8", rec.Header().Get("Content-Type"), "Expected an iCalendar feed")
+	assert.Contains(t, rec.Body.String(), "DTSTART:20250506T020000Z\r\nDTEND:20250506T030000Z\r\nRRULE:FREQ=DAILY\r\n", "Expected the maintenance from today")
+	etag := rec.Header().Get("ETag")
+
+	s.Clock.(*fakeClock).Advance(time.Hour)
+	assert.Equal(t, http.StatusNotModified, serverGet(s, "/calendar.ics", "If-None-Match", etag).Code, "Expected the feed to be the same during the day")
+
+	s.Clock.(*fakeClock).Advance(24 * time.Hour)
+	rec = serverGet(s, "/calendar.ics", "If-None-Match", etag)
+	assert.Equal(t, http.StatusOK, rec.Code, "Expected a new feed the next day")
+	assert.Contains(t, rec.Body.String(), "DTSTART:20250507T020000Z", "Expected the maintenance from the next day")
+}
+
+func TestServerErrors(t *testing.T) {
+	s := &Server{
+		Live: newTestLive(t,
+			DailyPeriod{PeriodLabel: PeriodLabel{ID: "nightly", Name: "maintenance", Description: "update indexes"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+			WeeklyPeriod{PeriodLabel: PeriodLabel{Name: "freeze"}, From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+		),
+		Clock:     newFakeClock(time.Date(2025, 5, 6, 2, 30, 0, 0, time.UTC)),
+		ICalendar: ICalendar{Location: time.UTC},
+	}
+
+	assert.Equal(t, http.StatusNotFound, serverGet(s, "/other").Code, "Expected an unknown path to be not found")
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/status", strings.NewReader("{}")))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code, "Expected only reads to be allowed")
+	assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"), "Expected the allowed methods")
+}
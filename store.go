@@ -0,0 +1,68 @@
+package casoncelli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Store persists the schedules changed through an Admin.
+type Store interface {
+	// Load returns the stored schedule.
+	Load(ctx context.Context) (Casoncelli, error)
+	// Save replaces the stored schedule with c.
+	Save(ctx context.Context, c Casoncelli) error
+}
+
+// FileStore stores a schedule in a JSON file, in the format read by a
+// FileLoader. The file is replaced atomically, so that a FileLoader never
+// reads it half written.
+type FileStore struct {
+	// Path is the path of the schedule file.
+	Path string
+}
+
+// Load reads the schedule file.
+func (f FileStore) Load(context.Context) (Casoncelli, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return Casoncelli{}, err
+	}
+	var c Casoncelli
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Casoncelli{}, fmt.Errorf("%s: %w", f.Path, err)
+	}
+	return c, nil
+}
+
+// Save writes c to a temporary file next to the schedule file, and renames
+// it over the schedule file.
+func (f FileStore) Save(_ context.Context, c Casoncelli) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// the file keeps the permissions of the one it replaces
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(f.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
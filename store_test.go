@@ -0,0 +1,40 @@
+package casoncelli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schedule.json")
+	store := FileStore{Path: path}
+	_, err := store.Load(context.Background())
+	assert.Error(t, err, "Expected a missing file to not be loaded")
+
+	c := Casoncelli{Boundary: HalfOpen, Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}},
+		WeeklyPeriod{From: DayTimeEdge{Day: time.Friday, Hour: "16:00"}, To: DayTimeEdge{Day: time.Monday, Hour: "08:00"}},
+	}}
+	assert.NoError(t, store.Save(context.Background(), c), "Expected the schedule to be saved")
+	loaded, err := store.Load(context.Background())
+	assert.NoError(t, err, "Expected the schedule to be loaded")
+	assert.Equal(t, c, loaded, "Expected the saved schedule")
+
+	assert.NoError(t, os.Chmod(path, 0o600), "Expected the permissions to change")
+	assert.NoError(t, store.Save(context.Background(), Casoncelli{Periods: []Period{}}), "Expected the schedule to be replaced")
+	info, err := os.Stat(path)
+	assert.NoError(t, err, "Expected the file to exist")
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "Expected the permissions to be kept")
+	entries, err := os.ReadDir(filepath.Dir(path))
+	assert.NoError(t, err, "Expected the directory to be read")
+	assert.Len(t, entries, 1, "Expected no temporary files left")
+
+	assert.NoError(t, os.WriteFile(path, []byte("{"), 0o600), "Expected the file to be broken")
+	_, err = store.Load(context.Background())
+	assert.ErrorContains(t, err, path, "Expected the error to name the file")
+}
@@ -0,0 +1,115 @@
+package casoncelli
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// MaintenanceError is returned by a Transport for the requests to a host
+// under maintenance.
+type MaintenanceError struct {
+	// Host is the host of the request.
+	Host string
+	// Label is the label of the first active period.
+	Label PeriodLabel
+	// End is the expected end of the maintenance, zero if unknown.
+	End time.Time
+}
+
+func (e *MaintenanceError) Error() string {
+	if e.End.IsZero() {
+		return e.Host + " is under maintenance"
+	}
+	return e.Host + " is under maintenance until " + e.End.Format(time.RFC3339)
+}
+
+// Transport is an http.RoundTripper respecting the maintenance windows of
+// the hosts it calls.
+//
+// The requests to a host whose schedule is active fail with a
+// *MaintenanceError without reaching Base. With Wait, they are held until
+// the maintenance ends instead, unless its end is unknown, farther than
+// MaxWait, or after the deadline of the request context.
+type Transport struct {
+	// Base performs the requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+	// Hosts are the maintenance schedules by host. A request is matched by
+	// its host and port first, like "api.example.com:8443", then by its
+	// host name alone.
+	Hosts map[string]*Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+
+	// Wait holds the requests until the maintenance ends, instead of
+	// failing them.
+	Wait bool
+	// MaxWait is the longest a request is held, no limit if zero.
+	MaxWait time.Duration
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if live := t.schedule(r.URL); live != nil {
+		if err := t.wait(r.Context(), r.URL.Host, live); err != nil {
+			// a RoundTripper must close the body, even on errors
+			if r.Body != nil {
+				r.Body.Close()
+			}
+			return nil, err
+		}
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// schedule returns the schedule of the host of u, nil if it has none.
+func (t *Transport) schedule(u *url.URL) *Live {
+	if live, ok := t.Hosts[u.Host]; ok {
+		return live
+	}
+	return t.Hosts[u.Hostname()]
+}
+
+// wait returns once the schedule is not active, or the error to fail the
+// request with.
+func (t *Transport) wait(ctx context.Context, host string, live *Live) error {
+	clock := clockOrSystem(t.Clock)
+	for {
+		changed := live.Changed()
+		schedule := live.Schedule()
+		now := clock.Now()
+		if !schedule.Contains(now) {
+			return nil
+		}
+
+		err := &MaintenanceError{Host: host}
+		if active := schedule.Active(now); len(active) > 0 {
+			err.Label = active[0].Label
+		}
+		err.End, _ = schedule.CurrentEnd(now)
+		if !t.Wait || err.End.IsZero() {
+			return err
+		}
+		remaining := err.End.Sub(now)
+		if t.MaxWait > 0 && remaining > t.MaxWait {
+			return err
+		}
+		// the deadline is measured by the system clock
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < remaining {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		// a closed maintenance still includes its end
+		case <-clock.After(max(remaining, time.Nanosecond)):
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package casoncelli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+var okTransport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: http.StatusOK, Request: r}, nil
+})
+
+func TestTransportFailFast(t *testing.T) {
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+
+	_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/v1", nil))
+	var maintenance *MaintenanceError
+	assert.True(t, errors.As(err, &maintenance), "Expected a maintenance error")
+	assert.Equal(t, "api.example.com", maintenance.Host, "Expected the host of the request")
+	assert.Equal(t, "api maintenance", maintenance.Label.Name, "Expected the active period")
+	assert.True(t, time.Date(2025, 5, 5, 3, 0, 0, 0, time.UTC).Equal(maintenance.End), "Expected the end of the maintenance")
+	assert.Equal(t, "api.example.com is under maintenance until 2025-05-05T03:00:00Z", err.Error(), "Expected the error message")
+
+	_, err = tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com:8443/v1", nil))
+	assert.Error(t, err, "Expected the host name to match regardless of the port")
+}
+
+// closeRecorder is a request body recording whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestTransportClosesBody(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: clock,
+	}
+
+	body := &closeRecorder{Reader: strings.NewReader("{}")}
+	_, err := tr.RoundTrip(httptest.NewRequest(http.MethodPost, "https://api.example.com/v1", body))
+	assert.IsType(t, &MaintenanceError{}, err, "Expected a maintenance error")
+	assert.True(t, body.closed, "Expected the body to be closed when failing fast")
+
+	tr.Wait = true
+	ctx, cancel := context.WithCancel(context.Background())
+	body = &closeRecorder{Reader: strings.NewReader("{}")}
+	done := make(chan error)
+	go func() {
+		_, err := tr.RoundTrip(httptest.NewRequest(http.MethodPost, "https://api.example.com/v1", body).WithContext(ctx))
+		done <- err
+	}()
+	clock.waitCalls(t, 1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled, "Expected the context error")
+	assert.True(t, body.closed, "Expected the body to be closed when canceled")
+}
+
+func TestTransportForward(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: clock,
+	}
+
+	resp, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://other.example.com/", nil))
+	assert.NoError(t, err, "Expected the hosts without a schedule to be called")
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "Expected the response of the base transport")
+
+	clock.Jump(time.Hour)
+	_, err = tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil))
+	assert.NoError(t, err, "Expected the host to be called after the maintenance")
+}
+
+func TestTransportWait(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: clock,
+	}
+	tr.Wait = true
+
+	done := make(chan error)
+	go func() {
+		_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil))
+		done <- err
+	}()
+
+	clock.waitCalls(t, 1)
+	select {
+	case <-done:
+		assert.Fail(t, "Expected the request to be held during the maintenance")
+	default:
+	}
+	clock.Advance(31 * time.Minute)
+	assert.NoError(t, <-done, "Expected the request to be sent at the end of the maintenance")
+}
+
+func TestTransportWaitLimits(t *testing.T) {
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC)),
+	}
+	tr.Wait = true
+	tr.MaxWait = 10 * time.Minute
+
+	_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil))
+	assert.IsType(t, &MaintenanceError{}, err, "Expected to fail fast when the maintenance ends after MaxWait")
+
+	tr.MaxWait = 0
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil).WithContext(ctx)
+	_, err = tr.RoundTrip(r)
+	assert.IsType(t, &MaintenanceError{}, err, "Expected to fail fast when the maintenance ends after the deadline")
+}
+
+func TestTransportWaitCanceled(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: clock,
+	}
+	tr.Wait = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		r := httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil).WithContext(ctx)
+		_, err := tr.RoundTrip(r)
+		done <- err
+	}()
+
+	clock.waitCalls(t, 1)
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled, "Expected the context error when the request is canceled")
+}
+
+func TestTransportWaitReplaced(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 5, 5, 2, 30, 0, 0, time.UTC))
+	tr := &Transport{
+		Base:  okTransport,
+		Hosts: map[string]*Live{"api.example.com": newTestLive(t, DailyPeriod{PeriodLabel: PeriodLabel{Name: "api maintenance"}, From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}})},
+		Clock: clock,
+	}
+	tr.Wait = true
+
+	done := make(chan error)
+	go func() {
+		_, err := tr.RoundTrip(httptest.NewRequest(http.MethodGet, "https://api.example.com/", nil))
+		done <- err
+	}()
+
+	clock.waitCalls(t, 1)
+	_, err := tr.Hosts["api.example.com"].Replace(Casoncelli{})
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	assert.NoError(t, <-done, "Expected the request to be sent when the maintenance is canceled")
+}
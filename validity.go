@@ -0,0 +1,118 @@
+package casoncelli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoOccurrence is returned by the navigation methods of a recurring
+// period when no occurrence is left within its Validity.
+var ErrNoOccurrence = errors.New("no occurrence within the validity of the period")
+
+// Validity bounds a recurring period to the times in [ValidFrom, ValidUntil),
+// cutting the occurrences crossing the bounds. A missing bound leaves that
+// side open.
+type Validity struct {
+	ValidFrom  *TimestampEdge `json:"valid_from,omitempty"`
+	ValidUntil *TimestampEdge `json:"valid_until,omitempty"`
+}
+
+// limited reports whether the validity has at least one bound.
+func (v Validity) limited() bool {
+	return v.ValidFrom != nil || v.ValidUntil != nil
+}
+
+// covers reports whether t is within the validity.
+func (v Validity) covers(t time.Time) bool {
+	return (v.ValidFrom == nil || !t.Before(v.ValidFrom.Timestamp)) &&
+		(v.ValidUntil == nil || t.Before(v.ValidUntil.Timestamp))
+}
+
+func (v Validity) validate() error {
+	if v.ValidFrom != nil && v.ValidUntil != nil && !v.ValidUntil.Timestamp.After(v.ValidFrom.Timestamp) {
+		return fmt.Errorf("validity ends before it starts")
+	}
+	return nil
+}
+
+// clip returns the parts of the occurrences within the validity.
+func (v Validity) clip(occurrences []Occurrence) []Occurrence {
+	if !v.limited() {
+		return occurrences
+	}
+	var result []Occurrence
+	for _, occ := range occurrences {
+		startIn, endIn := occ.Boundary.includesStart(), occ.Boundary.includesEnd()
+		if v.ValidFrom != nil && occ.Start.Before(v.ValidFrom.Timestamp) {
+			occ.Start, startIn = v.ValidFrom.Timestamp, true
+		}
+		if v.ValidUntil != nil && !occ.End.Before(v.ValidUntil.Timestamp) {
+			occ.End, endIn = v.ValidUntil.Timestamp, false
+		}
+		if occ.Start.Before(occ.End) || (occ.Start.Equal(occ.End) && startIn && endIn) {
+			occ.Boundary = boundaryOf(startIn, endIn)
+			result = append(result, occ)
+		}
+	}
+	return result
+}
+
+// activeOccurrence returns the occurrence of o containing now.
+func activeOccurrence(o occurrer, now time.Time) (Occurrence, error) {
+	for _, occ := range o.occurrences(now, now) {
+		if occ.Contains(now) {
+			return occ, nil
+		}
+	}
+	return Occurrence{}, fmt.Errorf("period is not active")
+}
+
+// next returns the first occurrence of o starting after now, other than
+// the one containing now.
+func (v Validity) next(o occurrer, now time.Time) (Occurrence, error) {
+	from := now
+	if v.ValidFrom != nil && v.ValidFrom.Timestamp.After(now) {
+		from = v.ValidFrom.Timestamp
+	}
+	for _, occ := range o.occurrences(from, from.Add(recurringLookaround)) {
+		if occ.Start.After(now) && !occ.Contains(now) {
+			return occ, nil
+		}
+	}
+	return Occurrence{}, ErrNoOccurrence
+}
+
+// previous returns the last occurrence of o ending before now, other than
+// the one containing now.
+func (v Validity) previous(o occurrer, now time.Time) (Occurrence, error) {
+	to := now
+	if v.ValidUntil != nil && v.ValidUntil.Timestamp.Before(now) {
+		to = v.ValidUntil.Timestamp
+	}
+	occurrences := o.occurrences(to.Add(-recurringLookaround), to)
+	for i := len(occurrences) - 1; i >= 0; i-- {
+		if occ := occurrences[i]; occ.End.Before(now) && !occ.Contains(now) {
+			return occ, nil
+		}
+	}
+	return Occurrence{}, ErrNoOccurrence
+}
+
+// startOf returns the start of the occurrence found by the navigation of a
+// period with a validity.
+func startOf(occ Occurrence, err error) (*time.Time, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &occ.Start, nil
+}
+
+// endOf returns the end of the occurrence found by the navigation of a
+// period with a validity.
+func endOf(occ Occurrence, err error) (*time.Time, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &occ.End, nil
+}
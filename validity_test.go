@@ -0,0 +1,179 @@
+package casoncelli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidityContains(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		WeeklyPeriod{
+			PeriodLabel: PeriodLabel{Name: "vendor maintenance"},
+			Validity: Validity{
+				ValidFrom:  &TimestampEdge{Timestamp: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+				ValidUntil: &TimestampEdge{Timestamp: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			From:     DayTimeEdge{Day: time.Tuesday, Hour: "22:00"},
+			To:       DayTimeEdge{Day: time.Wednesday, Hour: "02:00"},
+			Boundary: HalfOpen,
+		},
+	}}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	for _, tc := range []struct {
+		at       time.Time
+		expected bool
+	}{
+		{time.Date(2025, 2, 25, 23, 0, 0, 0, time.UTC), false},
+		{time.Date(2025, 3, 4, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 9, 30, 23, 0, 0, 0, time.UTC), true},
+		{time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), false},
+		{time.Date(2025, 10, 7, 23, 0, 0, 0, time.UTC), false},
+	} {
+		assert.Equal(t, tc.expected, c.Contains(tc.at), "Expected the Casoncelli to contain %s: %v", tc.at, tc.expected)
+		assert.Equal(t, tc.expected, s.Contains(tc.at), "Expected the Schedule to contain %s: %v", tc.at, tc.expected)
+	}
+
+	var ts []time.Time
+	for at := time.Date(2025, 9, 20, 0, 0, 0, 0, time.UTC); at.Before(time.Date(2025, 10, 10, 0, 0, 0, 0, time.UTC)); at = at.Add(30 * time.Minute) {
+		ts = append(ts, at)
+	}
+	for i, m := range s.Evaluate(ts) {
+		assert.Equal(t, c.Contains(ts[i]), m.Contained, "Expected the membership at %s to match Contains", ts[i])
+	}
+}
+
+func TestValidityOccurrences(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		WeeklyPeriod{
+			PeriodLabel: PeriodLabel{Name: "vendor maintenance"},
+			Validity: Validity{
+				ValidFrom:  &TimestampEdge{Timestamp: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+				ValidUntil: &TimestampEdge{Timestamp: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			From:     DayTimeEdge{Day: time.Tuesday, Hour: "22:00"},
+			To:       DayTimeEdge{Day: time.Wednesday, Hour: "02:00"},
+			Boundary: HalfOpen,
+		},
+	}}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	occurrences := s.Occurrences(time.Date(2025, 9, 20, 0, 0, 0, 0, time.UTC), time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC))
+	assert.Len(t, occurrences, 2, "Expected no occurrence after the validity")
+	assert.Equal(t, time.Date(2025, 9, 30, 22, 0, 0, 0, time.UTC), occurrences[1].Start, "Expected the last occurrence to start as usual")
+	assert.Equal(t, time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), occurrences[1].End, "Expected the last occurrence to be cut at the end of the validity")
+	assert.Equal(t, HalfOpen, occurrences[1].Boundary, "Expected the end of the validity to be excluded")
+
+	next := s.Next(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 1)
+	assert.Equal(t, time.Date(2025, 3, 4, 22, 0, 0, 0, time.UTC), next[0].Start, "Expected the first occurrence within the validity")
+	assert.Empty(t, s.Next(time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC), 1), "Expected no occurrence after the validity")
+}
+
+func TestValidityOpenStart(t *testing.T) {
+	validFrom := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	c := Casoncelli{Periods: []Period{
+		DailyPeriod{Validity: Validity{ValidFrom: &TimestampEdge{Timestamp: validFrom}}, From: TimeEdge{Hour: "12:00"}, To: TimeEdge{Hour: "14:00"}, Boundary: Open},
+	}}
+	s, err := c.Compile()
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	assert.False(t, s.Contains(validFrom), "Expected the open period to not contain its start")
+	assert.Empty(t, s.Active(validFrom), "Expected no active occurrence at the open start")
+	occurrences := s.Occurrences(validFrom.Add(-time.Hour), validFrom.Add(time.Hour))
+	assert.Len(t, occurrences, 1, "Expected the first occurrence within the validity")
+	assert.Equal(t, Open, occurrences[0].Boundary, "Expected the start of the occurrence to stay excluded")
+	merged := s.Merged(validFrom.Add(-time.Hour), validFrom.Add(time.Hour))
+	assert.False(t, merged[0].Contains(validFrom), "Expected the merged occurrence to not contain the open start")
+}
+
+func TestValidityNavigation(t *testing.T) {
+	now := time.Now()
+	p := DailyPeriod{From: TimeEdge{Hour: "02:00"}, To: TimeEdge{Hour: "03:00"}}
+
+	expired := p
+	expired.ValidUntil = &TimestampEdge{Timestamp: now.AddDate(0, 0, -10)}
+	_, err := expired.NextStart()
+	assert.ErrorIs(t, err, ErrNoOccurrence, "Expected no next start after the validity")
+	_, err = expired.NextEnd()
+	assert.ErrorIs(t, err, ErrNoOccurrence, "Expected no next end after the validity")
+	start, err := expired.PreviousStart()
+	assert.NoError(t, err, "Expected the last occurrence within the validity")
+	assert.True(t, start.Before(expired.ValidUntil.Timestamp), "Expected the previous start before the end of the validity")
+	assert.WithinDuration(t, expired.ValidUntil.Timestamp, *start, 24*time.Hour, "Expected the last occurrence of the validity")
+	assert.False(t, expired.ContainsNow(), "Expected the period to be inactive after the validity")
+
+	upcoming := p
+	upcoming.ValidFrom = &TimestampEdge{Timestamp: now.AddDate(0, 0, 10)}
+	_, err = upcoming.PreviousStart()
+	assert.ErrorIs(t, err, ErrNoOccurrence, "Expected no previous start before the validity")
+	_, err = upcoming.PreviousEnd()
+	assert.ErrorIs(t, err, ErrNoOccurrence, "Expected no previous end before the validity")
+	start, err = upcoming.NextStart()
+	assert.NoError(t, err, "Expected the first occurrence within the validity")
+	assert.False(t, start.Before(upcoming.ValidFrom.Timestamp), "Expected the next start within the validity")
+	assert.WithinDuration(t, upcoming.ValidFrom.Timestamp, *start, 24*time.Hour, "Expected the first occurrence of the validity")
+	end, err := upcoming.NextEnd()
+	assert.NoError(t, err, "Expected the first occurrence within the validity")
+	assert.Equal(t, start.Add(time.Hour), *end, "Expected the end of the first occurrence")
+	_, err = upcoming.CurrentStart()
+	assert.EqualError(t, err, "period is not active", "Expected the period to be inactive before the validity")
+
+	ongoing := p
+	ongoing.ValidFrom = &TimestampEdge{Timestamp: now.AddDate(0, 0, -10)}
+	start, err = ongoing.NextStart()
+	assert.NoError(t, err, "Expected the next start within the validity")
+	assert.True(t, start.After(now) && start.Before(now.Add(25*time.Hour)), "Expected the next start within a day")
+}
+
+func TestValidityJSON(t *testing.T) {
+	var c Casoncelli
+	err := json.Unmarshal([]byte(`{"periods": [
+		{"type": "weekly", "from": {"day": "tuesday", "hour": "22:00"}, "to": {"day": "wednesday", "hour": "02:00"},
+		 "valid_from": {"timestamp": "2025-03-01 00:00:00"}, "valid_until": {"timestamp": "2025-10-01 00:00:00"}},
+		{"type": "daily", "from": {"hour": "02:00"}, "to": {"hour": "03:00"}}
+	]}`), &c)
+	assert.NoError(t, err, "Expected the validity to be unmarshaled")
+	weekly := c.Periods[0].(WeeklyPeriod)
+	assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.Local), weekly.ValidFrom.Timestamp, "Expected the start of the validity")
+	assert.Equal(t, time.Date(2025, 10, 1, 0, 0, 0, 0, time.Local), weekly.ValidUntil.Timestamp, "Expected the end of the validity")
+	assert.False(t, c.Periods[1].(DailyPeriod).limited(), "Expected no validity by default")
+
+	data, err := json.Marshal(c.Periods[1])
+	assert.NoError(t, err, "Expected the period to be marshaled")
+	assert.NotContains(t, string(data), "valid_", "Expected no validity to be omitted")
+
+	weekly.ValidUntil = weekly.ValidFrom
+	c = Casoncelli{Periods: []Period{weekly}}
+	assert.EqualError(t, c.Validate(), "period 0: validity ends before it starts", "Expected an empty validity to be refused")
+}
+
+func TestValidityExport(t *testing.T) {
+	c := Casoncelli{Periods: []Period{
+		WeeklyPeriod{
+			PeriodLabel: PeriodLabel{Name: "vendor maintenance"},
+			Validity: Validity{
+				ValidFrom:  &TimestampEdge{Timestamp: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+				ValidUntil: &TimestampEdge{Timestamp: time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			From:     DayTimeEdge{Day: time.Tuesday, Hour: "22:00"},
+			To:       DayTimeEdge{Day: time.Wednesday, Hour: "02:00"},
+			Boundary: HalfOpen,
+		},
+	}}
+	ic := ICalendar{Location: time.UTC, Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Clock: newFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))}
+
+	var b bytes.Buffer
+	assert.NoError(t, ic.Export(&b, c), "Expected the schedule to be exported")
+	assert.Contains(t, b.String(), "DTSTART:20250304T220000Z\r\nDTEND:20250305T020000Z\r\nRRULE:FREQ=WEEKLY;BYDAY=TU;UNTIL=20250930T235959Z\r\n", "Expected the event to recur within the validity")
+
+	b.Reset()
+	ic.Since = time.Date(2025, 11, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, ic.Export(&b, c), "Expected the schedule to be exported")
+	assert.NotContains(t, b.String(), "BEGIN:VEVENT", "Expected no event after the validity")
+}
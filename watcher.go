@@ -0,0 +1,272 @@
+package casoncelli
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultWatchInterval is the longest a Watcher sleeps without one, before
+// checking the schedule again.
+const DefaultWatchInterval = time.Minute
+
+// EventKind tells whether an Event is the start or the end of an occurrence.
+type EventKind int
+
+const (
+	// Enter is delivered when an occurrence of a period starts.
+	Enter EventKind = iota
+	// Exit is delivered when an occurrence of a period ends.
+	Exit
+	// Starting is delivered ahead of the start of an occurrence, as requested by a Notice.
+	Starting
+	// Ending is delivered ahead of the end of an occurrence, as requested by a Notice.
+	Ending
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Enter:
+		return "enter"
+	case Exit:
+		return "exit"
+	case Starting:
+		return "starting"
+	case Ending:
+		return "ending"
+	default:
+		return fmt.Sprintf("EventKind(%d)", int(k))
+	}
+}
+
+// Event is a transition of a period, entering or exiting one of its
+// occurrences, or the advance notice of one.
+type Event struct {
+	Kind       EventKind
+	Occurrence Occurrence
+	// Before is the lead time of the Notice that produced a Starting or
+	// Ending event.
+	Before time.Duration
+}
+
+// Notice asks a Watcher to announce the start or the end of the occurrences
+// some time in advance, like "starts in 1h" or "ends in 5m".
+//
+// Every occurrence is announced at most once per Notice. When the watcher
+// starts, or wakes up, after the time of several notices of the same edge,
+// only the one with the shortest lead time is delivered.
+type Notice struct {
+	// Kind is Starting to announce the start of the occurrences, Ending to
+	// announce their end.
+	Kind EventKind
+	// Before is how long before the edge the notice is delivered.
+	Before time.Duration
+	// Name, if set, restricts the notice to the periods with this name.
+	Name string
+}
+
+// Watcher delivers an Event every time an occurrence of a period of a Live
+// schedule starts or ends.
+//
+// The watcher sleeps until the next transition, but never longer than
+// Interval: after waking up it compares the occurrences active at the
+// current wall clock with the ones it already reported, so the events are
+// still consistent after the schedule is replaced or the wall clock jumps.
+// Occurrences which started and ended while the watcher was not looking are
+// reported as an Enter immediately followed by an Exit.
+//
+// The overrides are honored: an inactive override exits the occurrences it
+// covers, and enters them again when it ends, while an active override is
+// entered and exited like an occurrence with Index -1.
+//
+// Only the periods defined by this package are watched.
+type Watcher struct {
+	// Live is the watched schedule.
+	Live *Live
+	// Clock is the source of time, SystemClock if nil.
+	Clock Clock
+	// Interval is the longest the watcher sleeps, DefaultWatchInterval if zero.
+	Interval time.Duration
+	// Notices are the advance notices to deliver.
+	Notices []Notice
+}
+
+// occurrenceKey identifies an occurrence of a period.
+type occurrenceKey struct {
+	index int
+	start int64
+}
+
+func keyOf(occ Occurrence) occurrenceKey {
+	if occ.Unbounded() {
+		return occurrenceKey{index: occ.Index}
+	}
+	return occurrenceKey{index: occ.Index, start: occ.Start.UnixNano()}
+}
+
+// Run watches the schedule until ctx is done, calling handle with every
+// event, and returns the context error. The occurrences already active when
+// Run starts are reported as Enter events.
+func (w *Watcher) Run(ctx context.Context, handle func(Event)) error {
+	clock := clockOrSystem(w.Clock)
+	interval := w.Interval
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	active := map[occurrenceKey]Occurrence{}
+	announced := map[noticeKey]time.Duration{}
+	var last time.Time
+	for {
+		changed := w.Live.Changed()
+		schedule := w.Live.Schedule()
+		now := clock.Now()
+		if last.IsZero() || last.After(now) || now.Sub(last) > recurringLookaround {
+			last = now
+		}
+
+		events, current := transitions(schedule, active, last, now)
+		notices, next := w.notices(schedule, announced, now)
+		for _, event := range append(events, notices...) {
+			handle(event)
+		}
+		active, last = current, now
+
+		wait := interval
+		if edge, ok := schedule.nextEdge(now); ok && (next.IsZero() || edge.Before(next)) {
+			next = edge
+		}
+		if !next.IsZero() && next.Sub(now) < wait {
+			wait = next.Sub(now)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-clock.After(wait):
+		}
+	}
+}
+
+// Events is like Run, but delivers the events on a channel which is closed
+// when ctx is done.
+func (w *Watcher) Events(ctx context.Context) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		w.Run(ctx, func(e Event) {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+	return events
+}
+
+// transitions compares the occurrences of the schedule active at now with the
+// previously active ones, returning the events between them along with the
+// new active occurrences. The occurrences started after last and already
+// ended are reported too.
+func transitions(s *Schedule, active map[occurrenceKey]Occurrence, last, now time.Time) ([]Event, map[occurrenceKey]Occurrence) {
+	from, to := last.Add(-recurringLookaround), now.Add(recurringLookaround)
+	occurrences := s.withOverrides(s.Occurrences(from, to), from, to)
+	current := map[occurrenceKey]Occurrence{}
+	for _, occ := range occurrences {
+		if s.inEffect(occ, now) {
+			current[keyOf(occ)] = occ
+		}
+	}
+
+	var exits []Occurrence
+	for key, occ := range active {
+		if _, ok := current[key]; !ok {
+			exits = append(exits, occ)
+		}
+	}
+	slices.SortFunc(exits, func(a, b Occurrence) int {
+		if c := a.End.Compare(b.End); c != 0 {
+			return c
+		}
+		return compareOccurrences(a, b)
+	})
+
+	var events []Event
+	for _, occ := range exits {
+		events = append(events, Event{Kind: Exit, Occurrence: occ})
+	}
+	for _, occ := range occurrences {
+		key := keyOf(occ)
+		_, wasActive := active[key]
+		_, isActive := current[key]
+		switch {
+		case isActive && !wasActive:
+			events = append(events, Event{Kind: Enter, Occurrence: occ})
+		case !isActive && !wasActive && len(s.transitionsOf(occ, Enter, last, now)) > 0:
+			events = append(events, Event{Kind: Enter, Occurrence: occ}, Event{Kind: Exit, Occurrence: occ})
+		}
+	}
+	return events, current
+}
+
+// noticeKey identifies an announced edge of an occurrence.
+type noticeKey struct {
+	occurrence occurrenceKey
+	kind       EventKind
+	edge       int64
+}
+
+// notices returns the advance notices due at now which have not been
+// announced yet, recording them in announced, along with the time the next
+// notice will be due.
+func (w *Watcher) notices(s *Schedule, announced map[noticeKey]time.Duration, now time.Time) ([]Event, time.Time) {
+	if len(w.Notices) == 0 {
+		return nil, time.Time{}
+	}
+	for key := range announced {
+		if key.edge <= now.UnixNano() {
+			delete(announced, key)
+		}
+	}
+
+	var longest time.Duration
+	for _, n := range w.Notices {
+		longest = max(longest, n.Before)
+	}
+
+	var events []Event
+	var next time.Time
+	horizon := now.Add(longest + recurringLookaround)
+	for _, occ := range s.withOverrides(s.Occurrences(now, horizon), now, horizon) {
+		for _, kind := range []EventKind{Starting, Ending} {
+			transition := Enter
+			if kind == Ending {
+				transition = Exit
+			}
+			for _, edge := range s.transitionsOf(occ, transition, now, horizon) {
+				due := time.Duration(-1)
+				for _, n := range w.Notices {
+					if n.Kind != kind || (n.Name != "" && n.Name != occ.Label.Name) {
+						continue
+					}
+					at := edge.Add(-n.Before)
+					if at.After(now) {
+						if next.IsZero() || at.Before(next) {
+							next = at
+						}
+					} else if due < 0 || n.Before < due {
+						due = n.Before
+					}
+				}
+
+				key := noticeKey{occurrence: keyOf(occ), kind: kind, edge: edge.UnixNano()}
+				if previous, ok := announced[key]; due >= 0 && (!ok || due < previous) {
+					announced[key] = due
+					events = append(events, Event{Kind: kind, Occurrence: occ, Before: due})
+				}
+			}
+		}
+	}
+	return events, next
+}
@@ -0,0 +1,287 @@
+package casoncelli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type watcherTest struct {
+	t      *testing.T
+	clock  *fakeClock
+	live   *Live
+	events chan Event
+	cancel context.CancelFunc
+	done   chan error
+}
+
+// startWatcher runs w on a fake clock set at now, watching c.
+func startWatcher(t *testing.T, now time.Time, c Casoncelli, w Watcher) *watcherTest {
+	live, err := NewLive(c)
+	assert.NoError(t, err, "Expected the schedule to compile")
+
+	wt := &watcherTest{
+		t:      t,
+		clock:  newFakeClock(now),
+		live:   live,
+		events: make(chan Event, 16),
+		done:   make(chan error),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	wt.cancel = cancel
+	w.Live, w.Clock = live, wt.clock
+	go func() { wt.done <- w.Run(ctx, func(e Event) { wt.events <- e }) }()
+	wt.clock.waitCalls(t, 1)
+	t.Cleanup(func() {
+		cancel()
+		<-wt.done
+	})
+	return wt
+}
+
+// expect checks the next event.
+func (wt *watcherTest) expect(kind EventKind, name string, start time.Time) {
+	wt.t.Helper()
+	select {
+	case e := <-wt.events:
+		assert.Equal(wt.t, kind, e.Kind, "Expected an %s event", kind)
+		assert.Equal(wt.t, name, e.Occurrence.Label.Name, "Expected an event for %s", name)
+		assert.True(wt.t, start.Equal(e.Occurrence.Start), "Expected the occurrence to start at %s, got %s", start, e.Occurrence.Start)
+	case <-time.After(time.Second):
+		assert.Fail(wt.t, "Expected an event", "%s %s", kind, name)
+	}
+}
+
+// expectNone checks that no event has been delivered.
+func (wt *watcherTest) expectNone() {
+	wt.t.Helper()
+	select {
+	case e := <-wt.events:
+		assert.Fail(wt.t, "Expected no event", "%+v", e)
+	default:
+	}
+}
+
+func backupSchedule() Casoncelli {
+	return Casoncelli{Periods: []Period{
+		DailyPeriod{PeriodLabel: PeriodLabel{Name: "backup"}, From: TimeEdge{Hour: "10:00"}, To: TimeEdge{Hour: "11:00"}},
+	}}
+}
+
+func TestWatcherTransitions(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: 24 * time.Hour})
+	wt.expectNone()
+
+	wt.clock.Advance(time.Hour)
+	wt.clock.waitCalls(t, 2)
+	wt.expect(Enter, "backup", now.Add(time.Hour))
+
+	wt.clock.Advance(time.Hour)
+	wt.clock.waitCalls(t, 3)
+	wt.expect(Exit, "backup", now.Add(time.Hour))
+
+	wt.clock.Advance(23 * time.Hour)
+	wt.clock.waitCalls(t, 4)
+	wt.expect(Enter, "backup", now.Add(25*time.Hour))
+	wt.expectNone()
+}
+
+func TestWatcherReportsActiveOccurrencesOnStart(t *testing.T) {
+	now := time.Date(2025, 5, 5, 10, 30, 0, 0, time.UTC)
+	c := backupSchedule()
+	c.Periods = append(c.Periods, AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "always"}})
+	wt := startWatcher(t, now, c, Watcher{Interval: time.Hour})
+
+	wt.expect(Enter, "always", time.Time{})
+	wt.expect(Enter, "backup", now.Add(-30*time.Minute))
+	wt.expectNone()
+}
+
+func TestWatcherScheduleReplaced(t *testing.T) {
+	now := time.Date(2025, 5, 5, 10, 30, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: 24 * time.Hour})
+	wt.expect(Enter, "backup", now.Add(-30*time.Minute))
+
+	_, err := wt.live.Replace(Casoncelli{Periods: []Period{AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "emergency"}}}})
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	wt.clock.waitCalls(t, 2)
+	wt.expect(Exit, "backup", now.Add(-30*time.Minute))
+	wt.expect(Enter, "emergency", time.Time{})
+
+	_, err = wt.live.Replace(Casoncelli{})
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	wt.clock.waitCalls(t, 3)
+	wt.expect(Exit, "emergency", time.Time{})
+	wt.expectNone()
+}
+
+func TestWatcherOverrides(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	c := backupSchedule()
+	c.Overrides = []Override{
+		{From: now.Add(30 * time.Minute), Until: now.Add(90 * time.Minute), Reason: "storage migration"},
+		{ID: "incident", Active: true, From: now.Add(3 * time.Hour), Until: now.Add(4 * time.Hour)},
+	}
+	wt := startWatcher(t, now, c, Watcher{Interval: 24 * time.Hour})
+
+	// the backup is held back until the end of the inactive override
+	for calls := 2; calls <= 3; calls++ {
+		wt.clock.Advance(30 * time.Minute)
+		wt.clock.waitCalls(t, calls)
+		wt.expectNone()
+	}
+	wt.clock.Advance(30 * time.Minute)
+	wt.clock.waitCalls(t, 4)
+	wt.expect(Enter, "backup", now.Add(time.Hour))
+	wt.clock.Advance(30 * time.Minute)
+	wt.clock.waitCalls(t, 5)
+	wt.expect(Exit, "backup", now.Add(time.Hour))
+
+	wt.clock.Advance(time.Hour)
+	wt.clock.waitCalls(t, 6)
+	select {
+	case e := <-wt.events:
+		assert.Equal(t, Enter, e.Kind, "Expected the active override to be entered")
+		assert.Equal(t, -1, e.Occurrence.Index, "Expected the override to not refer to a period")
+		assert.Equal(t, "incident", e.Occurrence.Label.ID, "Expected the id of the override")
+	case <-time.After(time.Second):
+		assert.Fail(t, "Expected an event for the active override")
+	}
+	wt.clock.Advance(time.Hour)
+	wt.clock.waitCalls(t, 7)
+	wt.expect(Exit, "", now.Add(3*time.Hour))
+	wt.expectNone()
+}
+
+func TestWatcherWallClockJumpForward(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 0, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: 24 * time.Hour})
+
+	// the whole occurrence is skipped by the jump
+	wt.clock.Jump(3 * time.Hour)
+	wt.clock.Advance(time.Hour)
+	wt.clock.waitCalls(t, 2)
+	wt.expect(Enter, "backup", now.Add(time.Hour))
+	wt.expect(Exit, "backup", now.Add(time.Hour))
+	wt.expectNone()
+}
+
+func TestWatcherWallClockJumpBackward(t *testing.T) {
+	now := time.Date(2025, 5, 5, 10, 30, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: time.Minute})
+	wt.expect(Enter, "backup", now.Add(-30*time.Minute))
+
+	wt.clock.Jump(-90 * time.Minute)
+	wt.clock.Advance(time.Minute)
+	wt.clock.waitCalls(t, 2)
+	wt.expect(Exit, "backup", now.Add(-30*time.Minute))
+	wt.expectNone()
+}
+
+func TestWatcherEvents(t *testing.T) {
+	live, _ := NewLive(Casoncelli{Periods: []Period{AlwaysPeriod{PeriodLabel: PeriodLabel{Name: "always"}}}})
+	w := &Watcher{Live: live, Clock: newFakeClock(time.Now())}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Events(ctx)
+	e := <-events
+	assert.Equal(t, Enter, e.Kind, "Expected an enter event")
+	assert.Equal(t, "always", e.Occurrence.Label.Name, "Expected an event for the always period")
+
+	cancel()
+	_, open := <-events
+	assert.False(t, open, "Expected the channel to be closed when the context is done")
+}
+
+func TestEventKindString(t *testing.T) {
+	assert.Equal(t, "enter", Enter.String(), "Expected the enter kind name")
+	assert.Equal(t, "exit", Exit.String(), "Expected the exit kind name")
+	assert.Equal(t, "starting", Starting.String(), "Expected the starting kind name")
+	assert.Equal(t, "ending", Ending.String(), "Expected the ending kind name")
+}
+
+// expectNotice checks the next event is an advance notice.
+func (wt *watcherTest) expectNotice(kind EventKind, before time.Duration) {
+	wt.t.Helper()
+	select {
+	case e := <-wt.events:
+		assert.Equal(wt.t, kind, e.Kind, "Expected a %s event", kind)
+		assert.Equal(wt.t, before, e.Before, "Expected a notice %s before", before)
+	case <-time.After(time.Second):
+		assert.Fail(wt.t, "Expected a notice", "%s %s", kind, before)
+	}
+}
+
+func TestWatcherNotices(t *testing.T) {
+	now := time.Date(2025, 5, 5, 8, 0, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: 24 * time.Hour, Notices: []Notice{
+		{Kind: Starting, Before: time.Hour},
+		{Kind: Starting, Before: 10 * time.Minute},
+		{Kind: Ending, Before: 5 * time.Minute},
+	}})
+	wt.expectNone()
+
+	steps := []struct {
+		advance time.Duration
+		check   func()
+	}{
+		{time.Hour, func() { wt.expectNotice(Starting, time.Hour) }},
+		{50 * time.Minute, func() { wt.expectNotice(Starting, 10*time.Minute) }},
+		{10 * time.Minute, func() { wt.expect(Enter, "backup", now.Add(2*time.Hour)) }},
+		{55 * time.Minute, func() { wt.expectNotice(Ending, 5*time.Minute) }},
+		{5 * time.Minute, func() { wt.expect(Exit, "backup", now.Add(2*time.Hour)) }},
+	}
+	for i, step := range steps {
+		wt.clock.Advance(step.advance)
+		wt.clock.waitCalls(t, i+2)
+		step.check()
+		wt.expectNone()
+	}
+}
+
+func TestWatcherNoticesOnStart(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 30, 0, 0, time.UTC)
+	wt := startWatcher(t, now, backupSchedule(), Watcher{Interval: 24 * time.Hour, Notices: []Notice{
+		{Kind: Starting, Before: 24 * time.Hour},
+		{Kind: Starting, Before: time.Hour},
+		{Kind: Starting, Before: 10 * time.Minute},
+	}})
+	// tomorrow's occurrence is within 24h, today's one within 1h
+	wt.expectNotice(Starting, time.Hour)
+	wt.expectNone()
+
+	// replacing the schedule does not announce the same occurrence again
+	_, err := wt.live.Replace(backupSchedule())
+	assert.NoError(t, err, "Expected the schedule to be replaced")
+	wt.clock.waitCalls(t, 2)
+	wt.expectNone()
+
+	wt.clock.Advance(20 * time.Minute)
+	wt.clock.waitCalls(t, 3)
+	wt.expectNotice(Starting, 10*time.Minute)
+	wt.expectNone()
+
+	wt.clock.Advance(10 * time.Minute)
+	wt.clock.waitCalls(t, 4)
+	wt.expect(Enter, "backup", now.Add(30*time.Minute))
+	wt.expectNotice(Starting, 24*time.Hour)
+	wt.expectNone()
+}
+
+func TestWatcherNoticesByName(t *testing.T) {
+	now := time.Date(2025, 5, 5, 9, 30, 0, 0, time.UTC)
+	c := backupSchedule()
+	c.Periods = append(c.Periods, DailyPeriod{PeriodLabel: PeriodLabel{Name: "cleanup"}, From: TimeEdge{Hour: "09:45"}, To: TimeEdge{Hour: "09:50"}})
+	wt := startWatcher(t, now, c, Watcher{Interval: 24 * time.Hour, Notices: []Notice{{Kind: Starting, Before: time.Hour, Name: "cleanup"}}})
+
+	select {
+	case e := <-wt.events:
+		assert.Equal(t, "cleanup", e.Occurrence.Label.Name, "Expected only the named period to be announced")
+	case <-time.After(time.Second):
+		assert.Fail(t, "Expected a notice")
+	}
+	wt.expectNone()
+}
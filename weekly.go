@@ -10,20 +10,33 @@ import (
 
 type WeeklyPeriod struct {
 	PeriodLabel
-	From DayTimeEdge `json:"from"`
-	To   DayTimeEdge `json:"to"`
+	Validity
+	From     DayTimeEdge `json:"from"`
+	To       DayTimeEdge `json:"to"`
+	Boundary Boundary    `json:"boundary,omitempty"`
+}
+
+func (p WeeklyPeriod) MarshalJSON() ([]byte, error) {
+	type weekly WeeklyPeriod
+	return json.Marshal(struct {
+		Type string `json:"type"`
+		weekly
+	}{"weekly", weekly(p)})
 }
 
 // Contains reports whether the time instant t is included in the period.
 func (p WeeklyPeriod) Contains(t time.Time) bool {
+	if !p.covers(t) {
+		return false
+	}
 	switch {
 	case p.From.Day < p.To.Day || (p.From.Day == p.To.Day && p.From.Hour < p.To.Hour):
-		return p.From.BeforeOrEqual(t) && p.To.AfterOrEqual(t)
+		return p.Boundary.afterStart(p.From, t) && p.Boundary.beforeEnd(p.To, t)
 	case p.From.Day > p.To.Day || (p.From.Day == p.To.Day && p.From.Hour > p.To.Hour):
-		return p.To.AfterOrEqual(t) || p.From.BeforeOrEqual(t)
+		return p.Boundary.beforeEnd(p.To, t) || p.Boundary.afterStart(p.From, t)
 	default:
 		// this is the rare case where from and to are exactly the same
-		return p.From.Equal(t)
+		return p.Boundary.sameEdges(p.From, t)
 	}
 }
 
@@ -35,6 +48,9 @@ func (p WeeklyPeriod) ContainsNow() bool {
 // CurrentStart returns the start time of the current occurrance of the period, if active.
 func (p WeeklyPeriod) CurrentStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(activeOccurrence(p, now))
+	}
 	day := now.Weekday()
 	daysToRemove := 0
 	if p.Contains(now) {
@@ -53,10 +69,14 @@ func (p WeeklyPeriod) CurrentStart() (*time.Time, error) {
 // CurrentEnd returns the end time of the current occurrance of the period, if active.
 func (p WeeklyPeriod) CurrentEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(activeOccurrence(p, now))
+	}
 	day := now.Weekday()
 	daysToAdd := 0
 	if p.Contains(now) {
-		if p.To.Day > day || (p.To.Day == day && p.To.Hour >= now.Format("15:04")) {
+		hour := now.Format("15:04")
+		if p.To.Day > day || (p.To.Day == day && (p.To.Hour > hour || (p.Boundary.includesEnd() && p.To.Hour == hour))) {
 			daysToAdd = (int)(p.To.Day - day)
 		} else {
 			daysToAdd = (int)(7 - (day - p.To.Day))
@@ -71,6 +91,9 @@ func (p WeeklyPeriod) CurrentEnd() (*time.Time, error) {
 // NextStart returns the start time of the next occurrance of the period. If the period is active, it returns the start time of the next week.
 func (p WeeklyPeriod) NextStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(p.next(p, now))
+	}
 	if p.Contains(now) {
 		cs, _ := p.CurrentStart()
 		ns := cs.AddDate(0, 0, 7)
@@ -91,6 +114,9 @@ func (p WeeklyPeriod) NextStart() (*time.Time, error) {
 // NextEnd returns the end time of the next occurrance of the period. If the period is active, it returns the end time of the next week.
 func (p WeeklyPeriod) NextEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(p.next(p, now))
+	}
 	if p.Contains(now) {
 		ce, _ := p.CurrentEnd()
 		ne := ce.AddDate(0, 0, 7)
@@ -111,6 +137,9 @@ func (p WeeklyPeriod) NextEnd() (*time.Time, error) {
 // PreviousStart returns the start time of the previous occurrance of the period. If the period is active, it returns the start time of the previous week.
 func (p WeeklyPeriod) PreviousStart() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return startOf(p.previous(p, now))
+	}
 	if p.Contains(now) {
 		cs, _ := p.CurrentStart()
 		ps := cs.AddDate(0, 0, -7)
@@ -131,6 +160,9 @@ func (p WeeklyPeriod) PreviousStart() (*time.Time, error) {
 // PreviousEnd returns the end time of the previous occurrance of the period. If the period is active, it returns the end time of the previous week.
 func (p WeeklyPeriod) PreviousEnd() (*time.Time, error) {
 	now := time.Now()
+	if p.limited() {
+		return endOf(p.previous(p, now))
+	}
 	if p.Contains(now) {
 		ce, _ := p.CurrentEnd()
 		pe := ce.AddDate(0, 0, -7)
@@ -148,6 +180,29 @@ func (p WeeklyPeriod) PreviousEnd() (*time.Time, error) {
 	return &endTime, err
 }
 
+func (p WeeklyPeriod) validate() error {
+	if err := p.Boundary.validate(); err != nil {
+		return err
+	}
+	if err := p.Validity.validate(); err != nil {
+		return err
+	}
+	for _, edge := range []DayTimeEdge{p.From, p.To} {
+		if edge.Day < time.Sunday || edge.Day > time.Saturday {
+			return fmt.Errorf("invalid weekday: %d", edge.Day)
+		}
+		if _, err := parseHour(edge.Hour); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p WeeklyPeriod) withDefaultBoundary(b Boundary) Period {
+	p.Boundary = p.Boundary.or(b)
+	return p
+}
+
 type DayTimeEdge struct {
 	Day  time.Weekday `json:"day"`
 	Hour string       `json:"hour"`
@@ -185,6 +240,13 @@ func (d *DayTimeEdge) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+func (d DayTimeEdge) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Day  string `json:"day"`
+		Hour string `json:"hour"`
+	}{strings.ToLower(d.Day.String()), d.Hour})
+}
+
 // Before reports whether the edge is before the time instant t.
 func (e DayTimeEdge) Before(t time.Time) bool {
 	if e.Day < t.Weekday() {